@@ -0,0 +1,529 @@
+package ankrtest
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dwdwow/ankr-go"
+)
+
+// DefaultSeed seeds the dataset of a Server created without one
+const DefaultSeed = 1
+
+// TransferTopic is the topic of the ERC-20 and ERC-721 Transfer event, first topic of its logs
+const TransferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+// Size of a seeded dataset
+const (
+	datasetWallets    = 6
+	blocksPerChain    = 40
+	nftsPerCollection = 8
+)
+
+// datasetEnd is the timestamp of the latest block of every seeded chain
+var datasetEnd = time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+// seededChains are the chains of a seeded dataset
+var seededChains = []struct {
+	chain       ankr.Chain
+	latest      int64
+	blockTime   int64 // seconds
+	symbol      string
+	name        string
+	nativePrice float64
+}{
+	{ankr.ChainEthereum, 20_000_000, 12, "ETH", "Ethereum", 3400},
+	{ankr.ChainBSC, 40_000_000, 3, "BNB", "BNB", 580},
+	{ankr.ChainPolygon, 58_000_000, 2, "POL", "Polygon", 0.55},
+}
+
+// seededTokens are the ERC-20 tokens of every seeded chain, those without price aren't whitelisted
+var seededTokens = []struct {
+	symbol   string
+	name     string
+	decimals int32
+	price    float64
+}{
+	{"USDT", "Tether USD", 6, 1},
+	{"LINK", "ChainLink Token", 18, 14.5},
+	{"FREE", "Free Airdrop Token", 18, 0},
+}
+
+// Dataset is the in-memory data served by a Server
+//
+// Items are related the way they are on chain: every token and NFT transfer
+// is emitted by a transaction of Blocks, with its Transfer log.
+// A Dataset may be built by hand or edited before being passed to NewServer.
+type Dataset struct {
+	// Wallets are the accounts sending transactions and holding tokens and NFTs on every chain
+	Wallets []string
+
+	// Stats are the statistics of every chain; a chain is served only if it has stats
+	Stats []ankr.BlockchainStat
+
+	// Blocks of every chain, in ascending order, with their transactions and logs
+	Blocks []ankr.Block
+
+	TokenTransfers []ankr.TokenTransfer
+	NFTTransfers   []ankr.NFTTransfer
+
+	// Currencies are the native coin and tokens of every chain, the native coin having no address
+	Currencies []ankr.Currency
+
+	// Prices are the USD prices of the whitelisted currencies
+	Prices []ankr.GetTokenPriceResp
+
+	// Balances are the assets held by every wallet
+	Balances []ankr.TokenAsset
+
+	// HolderCounts are the daily holder counts of every token
+	HolderCounts []HolderCount
+
+	// NFTs are the NFTs held by every wallet
+	NFTs []NFTHolding
+}
+
+// HolderCount is a daily holder count of a token
+type HolderCount struct {
+	Blockchain      string
+	ContractAddress string
+
+	ankr.HolderCountHistory
+}
+
+// NFTHolding is an NFT and its owner
+type NFTHolding struct {
+	Owner string
+
+	NFT ankr.NFT
+
+	// TokenURL and Description complete the NFT in its metadata
+	TokenURL    string
+	Description string
+}
+
+// NewDataset generates a dataset of a few wallets active on Ethereum, BSC and Polygon
+//
+// The same seed always generates the same dataset.
+func NewDataset(seed uint64) *Dataset {
+	g := &generator{
+		rng:    rand.New(rand.NewPCG(seed, seed)),
+		d:      &Dataset{},
+		nonces: make(map[string]int64),
+	}
+	for range datasetWallets {
+		g.d.Wallets = append(g.d.Wallets, g.hex(20))
+	}
+	for _, spec := range seededChains {
+		g.chain(spec.chain, spec.latest, spec.blockTime, spec.symbol, spec.name, spec.nativePrice)
+	}
+	return g.d
+}
+
+type generator struct {
+	rng    *rand.Rand
+	d      *Dataset
+	nonces map[string]int64
+}
+
+// token is a currency of a chain being generated
+type token struct {
+	ankr.Currency
+	price float64
+}
+
+// collection is an NFT collection of a chain being generated
+type collection struct {
+	address string
+	name    string
+	symbol  string
+	owners  []string
+}
+
+func (g *generator) chain(chain ankr.Chain, latest, blockTime int64, symbol, name string, nativePrice float64) {
+	native := token{ankr.Currency{Blockchain: string(chain), Decimals: 18, Name: name, Symbol: symbol, Thumbnail: thumbnail(symbol)}, nativePrice}
+	g.currency(native)
+	tokens := make([]token, len(seededTokens))
+	for i, spec := range seededTokens {
+		tokens[i] = token{ankr.Currency{
+			Address:    g.hex(20),
+			Blockchain: string(chain),
+			Decimals:   spec.decimals,
+			Name:       spec.name,
+			Symbol:     spec.symbol,
+			Thumbnail:  thumbnail(spec.symbol),
+		}, spec.price}
+		g.currency(tokens[i])
+	}
+
+	for _, wallet := range g.d.Wallets {
+		g.balance(wallet, native, "NATIVE")
+		for _, token := range tokens {
+			if g.rng.IntN(3) > 0 {
+				g.balance(wallet, token, "ERC20")
+			}
+		}
+	}
+	for _, token := range tokens {
+		g.holderCounts(token)
+	}
+
+	nfts := &collection{address: g.hex(20), name: "Fake Apes", symbol: "FAPE"}
+	for range nftsPerCollection {
+		nfts.owners = append(nfts.owners, g.wallet())
+	}
+
+	var txCount, logCount int64
+	parentHash := g.hex(32)
+	for number := latest - blocksPerChain + 1; number <= latest; number++ {
+		block := g.block(chain, number, datasetEnd-(latest-number)*blockTime, parentHash)
+		var gasUsed, logIndex int64
+		for i := range 1 + g.rng.IntN(4) {
+			tx := g.tx(&block, i, tokens, nfts, &logIndex)
+			gasUsed += hexToInt(tx.GasUsed)
+			tx.CumulativeGasUsed = hexInt(gasUsed)
+			block.Transactions = append(block.Transactions, tx)
+		}
+		block.GasUsed = hexInt(gasUsed)
+		block.Details.EthBlock.GasUsed = gasUsed
+		block.TransactionsCount = int32(len(block.Transactions))
+		txCount += int64(len(block.Transactions))
+		logCount += logIndex
+		g.d.Blocks = append(g.d.Blocks, block)
+		parentHash = block.BlockHash
+	}
+
+	for id, owner := range nfts.owners {
+		tokenID := strconv.Itoa(id + 1)
+		g.d.NFTs = append(g.d.NFTs, NFTHolding{
+			Owner: owner,
+			NFT: ankr.NFT{
+				Blockchain:      string(chain),
+				CollectionName:  nfts.name,
+				ContractAddress: nfts.address,
+				ContractType:    "ERC721",
+				Name:            nfts.name + " #" + tokenID,
+				TokenID:         tokenID,
+				ImageURL:        "ipfs://fake-apes/" + tokenID + ".png",
+				Symbol:          nfts.symbol,
+				Traits: []ankr.NFTTrait{
+					{TraitType: "Background", Value: []string{"Blue", "Red", "Gold"}[id%3]},
+					{TraitType: "Eyes", Value: []string{"Bored", "Laser"}[id%2]},
+				},
+			},
+			TokenURL:    "ipfs://fake-apes/" + tokenID + ".json",
+			Description: "A fake ape of the ankrtest dataset",
+		})
+	}
+
+	g.d.Stats = append(g.d.Stats, ankr.BlockchainStat{
+		Blockchain:             string(chain),
+		TotalTransactionsCount: txCount,
+		TotalEventsCount:       logCount,
+		LatestBlockNumber:      latest,
+		BlockTimeMs:            blockTime * 1000,
+		NativeCoinUsdPrice:     formatFloat(nativePrice),
+	})
+}
+
+func (g *generator) currency(t token) {
+	g.d.Currencies = append(g.d.Currencies, t.Currency)
+	if t.price > 0 {
+		g.d.Prices = append(g.d.Prices, ankr.GetTokenPriceResp{
+			Blockchain:      t.Blockchain,
+			ContractAddress: t.Address,
+			UsdPrice:        formatFloat(t.price),
+		})
+	}
+}
+
+func (g *generator) balance(wallet string, t token, tokenType string) {
+	cents := 1 + g.rng.Int64N(1_000_000)
+	g.d.Balances = append(g.d.Balances, ankr.TokenAsset{
+		Balance:           formatCents(cents),
+		BalanceRawInteger: rawAmount(cents, t.Decimals).String(),
+		BalanceUsd:        strconv.FormatFloat(float64(cents)/100*t.price, 'f', 2, 64),
+		Blockchain:        t.Blockchain,
+		ContractAddress:   t.Address,
+		HolderAddress:     wallet,
+		Thumbnail:         t.Thumbnail,
+		TokenDecimals:     t.Decimals,
+		TokenName:         t.Name,
+		TokenPrice:        formatFloat(t.price),
+		TokenSymbol:       t.Symbol,
+		TokenType:         tokenType,
+	})
+}
+
+// holderCounts adds the holder counts of the last days of t, the last one matching its balances
+func (g *generator) holderCounts(t token) {
+	var holders int64
+	total := new(big.Int)
+	for _, asset := range g.d.Balances {
+		if asset.Blockchain == t.Blockchain && asset.ContractAddress == t.Address {
+			holders++
+			raw, _ := new(big.Int).SetString(asset.BalanceRawInteger, 10)
+			total.Add(total, raw)
+		}
+	}
+	end := time.Unix(datasetEnd, 0).UTC()
+	for day := 2; day >= 0; day-- {
+		count := max(holders-int64(day), 0)
+		g.d.HolderCounts = append(g.d.HolderCounts, HolderCount{
+			Blockchain:      t.Blockchain,
+			ContractAddress: t.Address,
+			HolderCountHistory: ankr.HolderCountHistory{
+				HolderCount:           count,
+				LastUpdatedAt:         end.AddDate(0, 0, -day).Format(time.RFC3339),
+				TotalAmount:           formatUnits(total, t.Decimals),
+				TotalAmountRawInteger: total.String(),
+			},
+		})
+	}
+}
+
+func (g *generator) block(chain ankr.Chain, number, timestamp int64, parentHash string) ankr.Block {
+	block := ankr.Block{
+		BlockHash:        g.hex(32),
+		Number:           hexInt(number),
+		BlockchainName:   string(chain),
+		LogsBloom:        "0x",
+		MixHash:          g.hex(32),
+		Nonce:            "0x0000000000000000",
+		ParentHash:       parentHash,
+		ReceiptsRoot:     g.hex(32),
+		Sha3Uncles:       g.hex(32),
+		StateRoot:        g.hex(32),
+		Miner:            g.hex(20),
+		Difficulty:       "0x0",
+		ExtraData:        "0x",
+		Size:             hexInt(1000 + g.rng.Int64N(50_000)),
+		GasLimit:         hexInt(30_000_000),
+		Timestamp:        hexInt(timestamp),
+		TransactionsRoot: g.hex(32),
+		TotalDifficulty:  "0x0",
+		Uncles:           []any{},
+	}
+	block.Details.EthBlock = ankr.EthBlockDetails{
+		Difficulty:      "0",
+		ExtraData:       block.ExtraData,
+		GasLimit:        30_000_000,
+		Miner:           block.Miner,
+		Nonce:           block.Nonce,
+		Sha3Uncles:      block.Sha3Uncles,
+		Size:            block.Size,
+		StateRoot:       block.StateRoot,
+		TotalDifficulty: "0",
+	}
+	return block
+}
+
+// tx generates the i-th transaction of block: a native transfer, a token transfer or an NFT transfer
+func (g *generator) tx(block *ankr.Block, i int, tokens []token, nfts *collection, logIndex *int64) ankr.Tx {
+	from := g.wallet()
+	tx := ankr.Tx{
+		BlockHash:        block.BlockHash,
+		BlockNumber:      block.Number,
+		Blockchain:       block.BlockchainName,
+		Gas:              hexInt(200_000),
+		GasPrice:         hexInt(1_000_000_000 + g.rng.Int64N(50_000_000_000)),
+		Hash:             g.hex(32),
+		Input:            "0x",
+		LogsBloom:        "0x",
+		R:                g.hex(32),
+		S:                g.hex(32),
+		V:                "0x1",
+		Status:           "0x1",
+		Timestamp:        block.Timestamp,
+		TransactionIndex: hexInt(int64(i)),
+		Type:             "0x2",
+		Value:            "0x0",
+	}
+
+	switch g.rng.IntN(3) {
+	case 0:
+		tx.GasUsed = hexInt(21_000)
+		tx.To = g.otherWallet(from)
+		tx.Value = "0x" + rawAmount(1+g.rng.Int64N(10_000), 18).Text(16)
+	case 1:
+		t := tokens[g.rng.IntN(len(tokens))]
+		to := g.otherWallet(from)
+		cents := 1 + g.rng.Int64N(100_000)
+		value := rawAmount(cents, t.Decimals)
+		tx.GasUsed = hexInt(50_000 + g.rng.Int64N(10_000))
+		tx.To = t.Address
+		tx.Input = "0xa9059cbb" + word(to) + word("0x"+value.Text(16))
+		tx.Method = transferMethod("transfer", "transfer(address,uint256)", "0xa9059cbb", to, value.String())
+		tx.Logs = []ankr.Log{g.log(&tx, t.Address, logIndex,
+			[]string{TransferTopic, "0x" + word(from), "0x" + word(to)}, "0x"+word("0x"+value.Text(16)),
+			transferEvent(from, to, "value", value.String(), false))}
+		g.d.TokenTransfers = append(g.d.TokenTransfers, ankr.TokenTransfer{
+			BlockHeight:     hexToInt(block.Number),
+			Blockchain:      block.BlockchainName,
+			ContractAddress: t.Address,
+			FromAddress:     from,
+			Thumbnail:       t.Thumbnail,
+			Timestamp:       hexToInt(block.Timestamp),
+			ToAddress:       to,
+			TokenDecimals:   t.Decimals,
+			TokenName:       t.Name,
+			TokenSymbol:     t.Symbol,
+			TransactionHash: tx.Hash,
+			Value:           formatCents(cents),
+			ValueRawInteger: value.String(),
+		})
+	case 2:
+		id := g.rng.IntN(len(nfts.owners))
+		tokenID := strconv.Itoa(id + 1)
+		from = nfts.owners[id]
+		to := g.otherWallet(from)
+		nfts.owners[id] = to
+		tx.GasUsed = hexInt(80_000 + g.rng.Int64N(20_000))
+		tx.To = nfts.address
+		tx.Input = "0x23b872dd" + word(from) + word(to) + word(hexInt(int64(id+1)))
+		tx.Method = transferMethod("transferFrom", "transferFrom(address,address,uint256)", "0x23b872dd", to, tokenID)
+		tx.Logs = []ankr.Log{g.log(&tx, nfts.address, logIndex,
+			[]string{TransferTopic, "0x" + word(from), "0x" + word(to), "0x" + word(hexInt(int64(id+1)))}, "0x",
+			transferEvent(from, to, "tokenId", tokenID, true))}
+		g.d.NFTTransfers = append(g.d.NFTTransfers, ankr.NFTTransfer{
+			BlockHeight:      hexToInt(block.Number),
+			Blockchain:       block.BlockchainName,
+			CollectionName:   nfts.name,
+			CollectionSymbol: nfts.symbol,
+			ContractAddress:  nfts.address,
+			FromAddress:      from,
+			ImageURL:         "ipfs://fake-apes/" + tokenID + ".png",
+			Name:             nfts.name + " #" + tokenID,
+			Timestamp:        hexToInt(block.Timestamp),
+			ToAddress:        to,
+			TokenID:          tokenID,
+			TransactionHash:  tx.Hash,
+			Type:             "ERC721",
+			Value:            "1",
+		})
+	}
+
+	tx.From = from
+	tx.Nonce = g.nonce(tx.Blockchain, from)
+	return tx
+}
+
+func (g *generator) log(tx *ankr.Tx, address string, logIndex *int64, topics []string, data string, event ankr.Event) ankr.Log {
+	log := ankr.Log{
+		Address:          address,
+		BlockHash:        tx.BlockHash,
+		BlockNumber:      tx.BlockNumber,
+		Data:             data,
+		Event:            event,
+		LogIndex:         hexInt(*logIndex),
+		Topics:           topics,
+		TransactionHash:  tx.Hash,
+		TransactionIndex: tx.TransactionIndex,
+	}
+	*logIndex++
+	return log
+}
+
+func (g *generator) nonce(chain, wallet string) string {
+	key := chain + "/" + wallet
+	nonce := g.nonces[key]
+	g.nonces[key]++
+	return hexInt(nonce)
+}
+
+// hex returns n random bytes as a 0x-prefixed hex string
+func (g *generator) hex(n int) string {
+	var b strings.Builder
+	b.WriteString("0x")
+	for range n {
+		fmt.Fprintf(&b, "%02x", g.rng.IntN(256))
+	}
+	return b.String()
+}
+
+func (g *generator) wallet() string {
+	return g.d.Wallets[g.rng.IntN(len(g.d.Wallets))]
+}
+
+func (g *generator) otherWallet(wallet string) string {
+	for {
+		if other := g.wallet(); other != wallet {
+			return other
+		}
+	}
+}
+
+func transferMethod(name, signature, id, to, value string) ankr.Method {
+	return ankr.Method{
+		ID:   id,
+		Name: name,
+		Inputs: []ankr.MethodInput{
+			{Name: "to", Size: 160, Type: "address", ValueDecoded: to},
+			{Name: "value", Size: 256, Type: "uint256", ValueDecoded: value},
+		},
+		Signature: signature,
+		String:    "function " + signature,
+		Verified:  true,
+	}
+}
+
+func transferEvent(from, to, valueName, value string, indexed bool) ankr.Event {
+	return ankr.Event{
+		ID:   TransferTopic,
+		Name: "Transfer",
+		Inputs: []ankr.EventInput{
+			{Indexed: true, Name: "from", Size: 160, Type: "address", ValueDecoded: from},
+			{Indexed: true, Name: "to", Size: 160, Type: "address", ValueDecoded: to},
+			{Indexed: indexed, Name: valueName, Size: 256, Type: "uint256", ValueDecoded: value},
+		},
+		Signature: "Transfer(address,address,uint256)",
+		String:    "event Transfer(address indexed from, address indexed to, uint256 " + valueName + ")",
+		Verified:  true,
+	}
+}
+
+func thumbnail(symbol string) string {
+	return "https://assets.example.com/" + strings.ToLower(symbol) + ".png"
+}
+
+// word left-pads a 0x-prefixed hex value to a 32-byte ABI word, without prefix
+func word(hex string) string {
+	return fmt.Sprintf("%064s", strings.TrimPrefix(hex, "0x"))
+}
+
+func hexInt(n int64) string {
+	return "0x" + strconv.FormatInt(n, 16)
+}
+
+func hexToInt(hex string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimPrefix(hex, "0x"), 16, 64)
+	return n
+}
+
+// rawAmount converts an amount in hundredths to its raw integer with decimals
+func rawAmount(cents int64, decimals int32) *big.Int {
+	exp := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals-2)), nil)
+	return exp.Mul(exp, big.NewInt(cents))
+}
+
+func formatCents(cents int64) string {
+	return formatFloat(float64(cents) / 100)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatUnits formats a raw integer amount with decimals
+func formatUnits(raw *big.Int, decimals int32) string {
+	s := fmt.Sprintf("%0*s", decimals+1, raw.String())
+	whole, frac := s[:len(s)-int(decimals)], strings.TrimRight(s[len(s)-int(decimals):], "0")
+	if frac == "" {
+		return whole
+	}
+	return whole + "." + frac
+}
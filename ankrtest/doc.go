@@ -0,0 +1,18 @@
+// Package ankrtest provides tools to test code built on the ankr client without network access
+//
+// Recorder is an http.RoundTripper that records real JSON-RPC calls into fixture files
+// and replays them offline:
+//
+//	recorder, err := ankrtest.NewRecorder(ankrtest.RecorderConfig{Dir: "testdata/fixtures"})
+//	client := ankr.NewHTTPClient(&ankr.HTTPClientConfig{APIKey: "any", Transport: recorder})
+//
+// Server is an in-process fake of the API serving a seeded Dataset, with faults injected per method:
+//
+//	server := ankrtest.NewServer(nil)
+//	defer server.Close()
+//	server.Inject(ankr.MethodGetLogs, ankrtest.Fault{StatusCode: http.StatusTooManyRequests, Times: 1})
+//	client := server.Client(&ankr.HTTPClientConfig{})
+//
+// FaultTransport wraps any transport and injects faults, such as connection resets,
+// 5xx responses or truncated bodies, at configured rates.
+package ankrtest
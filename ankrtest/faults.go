@@ -0,0 +1,276 @@
+package ankrtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dwdwow/ankr-go"
+)
+
+// FaultKind is a kind of fault injected by a FaultTransport
+type FaultKind string
+
+const (
+	// FaultConnectionReset fails the request with a connection reset, before it is sent
+	FaultConnectionReset FaultKind = "connection_reset"
+
+	// FaultTimeout holds the request for FaultTransportConfig.TimeoutDelay, then fails it with a timeout
+	FaultTimeout FaultKind = "timeout"
+
+	// FaultRateLimit answers with a 429 and a Retry-After header
+	FaultRateLimit FaultKind = "rate_limit"
+
+	// FaultBadGateway answers with a 502
+	FaultBadGateway FaultKind = "bad_gateway"
+
+	// FaultServiceUnavailable answers with a 503
+	FaultServiceUnavailable FaultKind = "service_unavailable"
+
+	// FaultTruncatedBody sends the request and cuts the body of its response in half,
+	// reading it failing with io.ErrUnexpectedEOF
+	FaultTruncatedBody FaultKind = "truncated_body"
+
+	// FaultMalformedJSON answers with an HTTP 200 whose body isn't JSON
+	FaultMalformedJSON FaultKind = "malformed_json"
+
+	// FaultRPCError answers every call with FaultTransportConfig.RPCError inside an HTTP 200
+	FaultRPCError FaultKind = "rpc_error"
+)
+
+// faultKinds are the kinds of faults in the order they are drawn
+var faultKinds = []FaultKind{
+	FaultConnectionReset,
+	FaultTimeout,
+	FaultRateLimit,
+	FaultBadGateway,
+	FaultServiceUnavailable,
+	FaultTruncatedBody,
+	FaultMalformedJSON,
+	FaultRPCError,
+}
+
+// DefaultFaultRetryAfter is the Retry-After of injected 429 responses
+const DefaultFaultRetryAfter = time.Second
+
+// malformedBody is the body of FaultMalformedJSON responses, as sent by a misbehaving proxy
+const malformedBody = `{"jsonrpc":"2.0","result":<html><body>upstream error</body></html>`
+
+// FaultTransportConfig configures a FaultTransport
+type FaultTransportConfig struct {
+	// Transport sends the requests that aren't faulted (default: http.DefaultTransport)
+	Transport http.RoundTripper
+
+	// Rates are the fractions of requests, in [0, 1], failing with each kind of fault
+	// At most one fault is injected per request, so rates should sum to 1 at most.
+	Rates map[FaultKind]float64
+
+	// Methods restricts faults to requests calling one of these JSON-RPC methods, every request if empty
+	// A batch is faulted if any of its calls is.
+	Methods []string
+
+	// Seed seeds the draws of faults, so the same requests get the same faults on every run
+	Seed uint64
+
+	// RetryAfter is the Retry-After of FaultRateLimit responses, in whole seconds (default: DefaultFaultRetryAfter)
+	RetryAfter time.Duration
+
+	// TimeoutDelay is how long FaultTimeout requests are held before failing, unless their context ends first
+	TimeoutDelay time.Duration
+
+	// RPCError is the error of FaultRPCError responses (default: code -32000, retryable by the client)
+	RPCError *ankr.RPCRespError
+}
+
+// FaultTransport is an http.RoundTripper that injects faults at configured rates
+//
+// It wraps any transport, such as a Recorder or the default transport in front of a Server,
+// to exercise the retry, rate limiting, failover and pagination paths of the client.
+type FaultTransport struct {
+	transport    http.RoundTripper
+	rates        map[FaultKind]float64
+	methods      []string
+	retryAfter   time.Duration
+	timeoutDelay time.Duration
+	rpcError     *ankr.RPCRespError
+
+	mu       sync.Mutex
+	rng      *rand.Rand
+	injected map[FaultKind]int
+}
+
+// NewFaultTransport creates a FaultTransport
+func NewFaultTransport(config FaultTransportConfig) *FaultTransport {
+	t := &FaultTransport{
+		transport:    config.Transport,
+		rates:        config.Rates,
+		methods:      config.Methods,
+		retryAfter:   config.RetryAfter,
+		timeoutDelay: config.TimeoutDelay,
+		rpcError:     config.RPCError,
+		rng:          rand.New(rand.NewPCG(config.Seed, config.Seed)),
+		injected:     make(map[FaultKind]int),
+	}
+	if t.transport == nil {
+		t.transport = http.DefaultTransport
+	}
+	if t.retryAfter <= 0 {
+		t.retryAfter = DefaultFaultRetryAfter
+	}
+	if t.rpcError == nil {
+		t.rpcError = &ankr.RPCRespError{Code: -32000, Message: "ankrtest: injected fault"}
+	}
+	return t
+}
+
+// Injected returns the number of faults injected so far, by kind
+func (t *FaultTransport) Injected() map[FaultKind]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	injected := make(map[FaultKind]int, len(t.injected))
+	for kind, n := range t.injected {
+		injected[kind] = n
+	}
+	return injected
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// The caller's request must not be modified, the body is forwarded with a clone
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	reqs, batch := peekCalls(body)
+
+	kind, ok := t.draw(reqs)
+	if !ok {
+		return t.transport.RoundTrip(req)
+	}
+
+	switch kind {
+	case FaultConnectionReset:
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}}
+	case FaultTimeout:
+		timer := time.NewTimer(t.timeoutDelay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}
+	case FaultRateLimit:
+		resp := textResponse(req, http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests))
+		resp.Header.Set("Retry-After", strconv.Itoa(int(math.Ceil(t.retryAfter.Seconds()))))
+		return resp, nil
+	case FaultBadGateway:
+		return textResponse(req, http.StatusBadGateway, http.StatusText(http.StatusBadGateway)), nil
+	case FaultServiceUnavailable:
+		return textResponse(req, http.StatusServiceUnavailable, http.StatusText(http.StatusServiceUnavailable)), nil
+	case FaultTruncatedBody:
+		resp, err := t.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		full, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(full[:len(full)/2]), errReader{io.ErrUnexpectedEOF}))
+		resp.ContentLength = int64(len(full))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(full)))
+		return resp, nil
+	case FaultMalformedJSON:
+		resp := textResponse(req, http.StatusOK, malformedBody)
+		resp.Header.Set("Content-Type", "application/json")
+		return resp, nil
+	default: // FaultRPCError
+		resps := make([]rpcResponse, len(reqs))
+		for i, call := range reqs {
+			resps[i] = rpcResponse{JSONRPC: ankr.JSONRPC, ID: call.ID, Error: t.rpcError}
+		}
+		var data []byte
+		if batch {
+			data, _ = json.Marshal(resps)
+		} else {
+			data, _ = json.Marshal(resps[0])
+		}
+		resp := textResponse(req, http.StatusOK, string(data))
+		resp.Header.Set("Content-Type", "application/json")
+		return resp, nil
+	}
+}
+
+// draw decides which fault, if any, to inject into a request of reqs
+func (t *FaultTransport) draw(reqs []rpcRequest) (FaultKind, bool) {
+	if len(t.methods) > 0 && !slices.ContainsFunc(reqs, func(req rpcRequest) bool { return slices.Contains(t.methods, req.Method) }) {
+		return "", false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	u := t.rng.Float64()
+	for _, kind := range faultKinds {
+		if u -= t.rates[kind]; u < 0 {
+			t.injected[kind]++
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// peekCalls parses the calls of a request body, and whether it is a batch
+// A body that isn't JSON-RPC is treated as a single call without method.
+func peekCalls(body []byte) (reqs []rpcRequest, batch bool) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &reqs); err == nil && len(reqs) > 0 {
+			return reqs, true
+		}
+	}
+	var req rpcRequest
+	json.Unmarshal(trimmed, &req)
+	return []rpcRequest{req}, false
+}
+
+func textResponse(req *http.Request, statusCode int, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          io.NopCloser(bytes.NewReader([]byte(body))),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,128 @@
+package ankrtest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/ankr-go"
+)
+
+// TestFaultTransportKinds tests that every kind of fault surfaces as the matching client error
+func TestFaultTransportKinds(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+
+	tests := []struct {
+		kind  FaultKind
+		check func(err error) bool
+	}{
+		{FaultConnectionReset, func(err error) bool { return errors.Is(err, syscall.ECONNRESET) }},
+		{FaultTimeout, func(err error) bool {
+			var netErr net.Error
+			return errors.As(err, &netErr) && netErr.Timeout()
+		}},
+		{FaultRateLimit, func(err error) bool {
+			retryAfter, ok := ankr.RetryAfter(err)
+			return errors.Is(err, ankr.ErrRateLimited) && ok && retryAfter == DefaultFaultRetryAfter
+		}},
+		{FaultBadGateway, func(err error) bool {
+			var statusErr *ankr.HTTPStatusError
+			return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadGateway
+		}},
+		{FaultServiceUnavailable, func(err error) bool {
+			var statusErr *ankr.HTTPStatusError
+			return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusServiceUnavailable
+		}},
+		{FaultTruncatedBody, func(err error) bool { return errors.Is(err, io.ErrUnexpectedEOF) }},
+		{FaultMalformedJSON, func(err error) bool {
+			var decodeErr *ankr.DecodeError
+			return errors.As(err, &decodeErr)
+		}},
+		{FaultRPCError, func(err error) bool {
+			var rpcErr *ankr.RPCError
+			return errors.As(err, &rpcErr) && rpcErr.Code == -32000 && ankr.IsRetryable(err)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			transport := NewFaultTransport(FaultTransportConfig{Rates: map[FaultKind]float64{tt.kind: 1}})
+			client := server.Client(&ankr.HTTPClientConfig{
+				Transport:   transport,
+				RetryPolicy: ankr.NoRetry(),
+				Logger:      slog.New(slog.DiscardHandler),
+			})
+			_, err := client.GetBlockchainStats(context.Background(), ankr.GetBlockchainStatsReq{})
+			if err == nil || !tt.check(err) {
+				t.Errorf("Unexpected error for %s: %v", tt.kind, err)
+			}
+			if injected := transport.Injected(); injected[tt.kind] != 1 {
+				t.Errorf("Expected one %s fault, got %v", tt.kind, injected)
+			}
+		})
+	}
+}
+
+// TestFaultTransportPagination tests that retried pages survive faults and return every item once
+func TestFaultTransportPagination(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	wallet := server.Dataset().Wallets[0]
+
+	fetch := func(transport http.RoundTripper) []ankr.TokenTransfer {
+		client := server.Client(&ankr.HTTPClientConfig{
+			Transport:   transport,
+			RetryPolicy: &ankr.ExponentialBackoff{MaxAttempts: 20, InitialDelay: time.Millisecond, Jitter: -1},
+			Logger:      slog.New(slog.DiscardHandler),
+		})
+		pages := client.GetTokenTransfers(ankr.GetTokenTransfersReq{Address: []string{wallet}, PageSize: 2})
+		return collect(t, pages, func(page *ankr.GetTokenTransfersResp) []ankr.TokenTransfer { return page.Transfers })
+	}
+	newTransport := func() *FaultTransport {
+		return NewFaultTransport(FaultTransportConfig{
+			Transport: http.DefaultTransport,
+			Seed:      42,
+			Methods:   []string{ankr.MethodGetTokenTransfers},
+			Rates: map[FaultKind]float64{
+				FaultConnectionReset:    0.1,
+				FaultTimeout:            0.05,
+				FaultBadGateway:         0.1,
+				FaultServiceUnavailable: 0.05,
+				FaultTruncatedBody:      0.1,
+				FaultMalformedJSON:      0.05,
+				FaultRPCError:           0.05,
+			},
+		})
+	}
+
+	want := fetch(http.DefaultTransport)
+	transport := newTransport()
+	if got := fetch(transport); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the same %d transfers despite faults, got %d", len(want), len(got))
+	}
+	injected := transport.Injected()
+	if len(injected) == 0 {
+		t.Fatal("Expected faults to be injected")
+	}
+
+	again := newTransport()
+	fetch(again)
+	if !reflect.DeepEqual(again.Injected(), injected) {
+		t.Errorf("Expected the same seed to inject the same faults, got %v and %v", injected, again.Injected())
+	}
+
+	// Other methods are never faulted
+	client := server.Client(&ankr.HTTPClientConfig{Transport: transport, RetryPolicy: ankr.NoRetry()})
+	for range 20 {
+		if _, err := client.GetBlockchainStats(context.Background(), ankr.GetBlockchainStatsReq{}); err != nil {
+			t.Fatalf("Expected no fault outside Methods, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,510 @@
+package ankrtest
+
+import (
+	"cmp"
+	"encoding/json"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dwdwow/ankr-go"
+)
+
+func (s *Server) getNFTsByOwner(params json.RawMessage) (any, error) {
+	var req ankr.GetNFTsByOwnerReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	if req.WalletAddress == "" {
+		return nil, invalidParams("walletAddress is required")
+	}
+
+	var assets []ankr.NFT
+	for _, holding := range s.dataset.NFTs {
+		if strings.EqualFold(holding.Owner, req.WalletAddress) && chains.has(holding.NFT.Blockchain) && matchesFilter(req.Filter, holding.NFT) {
+			assets = append(assets, holding.NFT)
+		}
+	}
+	page, next, err := paginate(assets, req.PageToken, req.PageSize, defaultNFTsPageSize, maxNFTsPageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &ankr.GetNFTsByOwnerResp{Assets: page, NextPageToken: next}, nil
+}
+
+// matchesFilter reports whether nft matches a filter of contract addresses to token IDs,
+// an empty list of token IDs matching every NFT of the contract
+func matchesFilter(filter map[string][]string, nft ankr.NFT) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for contract, tokenIDs := range filter {
+		if strings.EqualFold(contract, nft.ContractAddress) && (len(tokenIDs) == 0 || slices.Contains(tokenIDs, nft.TokenID)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) getNFTMetadata(params json.RawMessage) (any, error) {
+	var req ankr.GetNFTMetadataReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := chains.single()
+	if err != nil {
+		return nil, err
+	}
+	if req.ContractAddress == "" || req.TokenID == "" {
+		return nil, invalidParams("contractAddress and tokenId are required")
+	}
+
+	for _, holding := range s.dataset.NFTs {
+		nft := holding.NFT
+		if nft.Blockchain != chain || !strings.EqualFold(nft.ContractAddress, req.ContractAddress) || nft.TokenID != req.TokenID {
+			continue
+		}
+		return &ankr.GetNFTMetadataResp{Metadata: ankr.NFTMetadata{
+			Blockchain:      nft.Blockchain,
+			ContractAddress: nft.ContractAddress,
+			ContractType:    nft.ContractType,
+			TokenID:         nft.TokenID,
+			Attributes: ankr.NFTMetadataAttributes{
+				ContractType: nft.ContractType,
+				TokenURL:     holding.TokenURL,
+				ImageURL:     nft.ImageURL,
+				Name:         nft.Name,
+				Description:  holding.Description,
+				Traits:       nft.Traits,
+			},
+		}}, nil
+	}
+	return nil, invalidParams("nft not found: %s %s #%s", chain, req.ContractAddress, req.TokenID)
+}
+
+func (s *Server) getNFTHolders(params json.RawMessage) (any, error) {
+	var req ankr.GetNFTHoldersReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := chains.single()
+	if err != nil {
+		return nil, err
+	}
+	if req.ContractAddress == "" {
+		return nil, invalidParams("contractAddress is required")
+	}
+
+	var holders []string
+	for _, holding := range s.dataset.NFTs {
+		nft := holding.NFT
+		if nft.Blockchain == chain && strings.EqualFold(nft.ContractAddress, req.ContractAddress) && !slices.Contains(holders, holding.Owner) {
+			holders = append(holders, holding.Owner)
+		}
+	}
+	page, next, err := paginate(holders, req.PageToken, req.PageSize, defaultHoldersPageSize, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &ankr.GetNFTHoldersResp{Holders: page, NextPageToken: next}, nil
+}
+
+func (s *Server) getNFTTransfers(params json.RawMessage) (any, error) {
+	var req ankr.GetNFTTransfersReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	r, err := parseRange(req.FromBlock, req.ToBlock, req.FromTimestamp, req.ToTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	var transfers []ankr.NFTTransfer
+	for _, t := range s.nftTransfers {
+		if chains.has(t.Blockchain) && s.inRange(r, t.Blockchain, t.BlockHeight, t.Timestamp) &&
+			matchesAny(req.Address, t.FromAddress, t.ToAddress, t.ContractAddress) {
+			transfers = append(transfers, t)
+		}
+	}
+	page, next, err := paginate(ordered(transfers, req.DescOrder), req.PageToken, req.PageSize, defaultTransfersPageSize, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &ankr.GetNFTTransfersResp{Transfers: page, NextPageToken: next}, nil
+}
+
+func (s *Server) getBlockchainStats(params json.RawMessage) (any, error) {
+	var req ankr.GetBlockchainStatsReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	stats := []ankr.BlockchainStat{}
+	for _, stat := range s.dataset.Stats {
+		if chains.has(stat.Blockchain) {
+			stats = append(stats, stat)
+		}
+	}
+	return &ankr.GetBlockchainStatsResp{Stats: stats}, nil
+}
+
+func (s *Server) getBlocks(params json.RawMessage) (any, error) {
+	var req ankr.GetBlocksReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := chains.single()
+	if err != nil {
+		return nil, err
+	}
+	r, err := parseRange(req.FromBlock, req.ToBlock, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	// A missing bound defaults to the other one, so a single block is returned by default
+	switch {
+	case !r.from.set && !r.to.set:
+		r.from, r.to = blockRef{set: true, latest: true}, blockRef{set: true, latest: true}
+	case !r.from.set:
+		r.from = r.to
+	case !r.to.set:
+		r.to = r.from
+	}
+	if latest := s.latest[chain]; r.from.resolve(latest) > r.to.resolve(latest) {
+		return nil, invalidParams("fromBlock is after toBlock")
+	}
+
+	includeTxs := req.IncludeTxs == nil || *req.IncludeTxs
+	blocks := []ankr.Block{}
+	for _, block := range s.dataset.Blocks {
+		if block.BlockchainName != chain || !s.inRange(r, chain, hexToInt(block.Number), 0) {
+			continue
+		}
+		txs := []ankr.Tx{}
+		if includeTxs {
+			for _, tx := range block.Transactions {
+				txs = append(txs, presentTx(tx, req.IncludeLogs, req.DecodeLogs, req.DecodeTxData))
+			}
+		}
+		block.Transactions = txs
+		blocks = append(blocks, block)
+	}
+	return &ankr.GetBlocksResp{Blocks: ordered(blocks, req.DescOrder)}, nil
+}
+
+// presentTx returns tx with its logs and decoded input only if asked for
+func presentTx(tx ankr.Tx, includeLogs, decodeLogs, decodeTxData bool) ankr.Tx {
+	if !decodeTxData {
+		tx.Method = ankr.Method{}
+	}
+	if !includeLogs {
+		tx.Logs = nil
+		return tx
+	}
+	logs := make([]ankr.Log, len(tx.Logs))
+	for i, log := range tx.Logs {
+		logs[i] = presentLog(log, decodeLogs)
+	}
+	tx.Logs = logs
+	return tx
+}
+
+// presentLog returns log with its decoded event only if asked for
+func presentLog(log ankr.Log, decode bool) ankr.Log {
+	if !decode {
+		log.Event = ankr.Event{}
+	}
+	return log
+}
+
+func (s *Server) getLogs(params json.RawMessage) (any, error) {
+	var req ankr.GetLogsReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	r, err := parseRange(req.FromBlock, req.ToBlock, req.FromTimestamp, req.ToTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []ankr.Log
+	for _, l := range s.logs {
+		if chains.has(l.chain) && s.inRange(r, l.chain, l.block, l.timestamp) &&
+			matchesAny(req.Address, l.log.Address) && matchesTopics(req.Topics, l.log.Topics) {
+			logs = append(logs, presentLog(l.log, req.DecodeLogs))
+		}
+	}
+	page, next, err := paginate(ordered(logs, req.DescOrder), req.PageToken, req.PageSize, maxPageSize, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &ankr.GetLogsResp{Logs: page, NextPageToken: next}, nil
+}
+
+func (s *Server) getTxsByHash(params json.RawMessage) (any, error) {
+	var req ankr.GetTxsByHashReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	if req.TransactionHash == "" {
+		return nil, invalidParams("transactionHash is required")
+	}
+
+	txs := []ankr.Tx{}
+	for _, tx := range s.txs {
+		if chains.has(tx.chain) && strings.EqualFold(tx.tx.Hash, req.TransactionHash) {
+			txs = append(txs, presentTx(tx.tx, req.IncludeLogs, req.DecodeLogs, req.DecodeTxData))
+		}
+	}
+	return &ankr.GetTxsByHashResp{Transactions: txs}, nil
+}
+
+func (s *Server) getTxsByAddress(params json.RawMessage) (any, error) {
+	var req ankr.GetTxsByAddressReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Address == "" {
+		return nil, invalidParams("address is required")
+	}
+	r, err := parseRange(req.FromBlock, req.ToBlock, req.FromTimestamp, req.ToTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []ankr.Tx
+	for _, tx := range s.txs {
+		if chains.has(tx.chain) && s.inRange(r, tx.chain, tx.block, tx.timestamp) && matchesAny([]string{req.Address}, tx.tx.From, tx.tx.To) {
+			txs = append(txs, presentTx(tx.tx, req.IncludeLogs, false, false))
+		}
+	}
+	page, next, err := paginate(ordered(txs, req.DescOrder), req.PageToken, req.PageSize, maxPageSize, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &ankr.GetTxsByAddressResp{Transactions: page, NextPageToken: next}, nil
+}
+
+func (s *Server) getInteractions(params json.RawMessage) (any, error) {
+	var req ankr.GetInteractionsReq
+	if _, err := s.decodeParams(params, &req); err != nil {
+		return nil, err
+	}
+	if req.Address == "" {
+		return nil, invalidParams("address is required")
+	}
+
+	active := make(map[string]bool)
+	for _, tx := range s.txs {
+		if matchesAny([]string{req.Address}, tx.tx.From, tx.tx.To) {
+			active[tx.chain] = true
+		}
+	}
+	for _, asset := range s.dataset.Balances {
+		if strings.EqualFold(asset.HolderAddress, req.Address) {
+			active[asset.Blockchain] = true
+		}
+	}
+	blockchains := []string{}
+	for _, chain := range s.chains {
+		if active[string(chain)] {
+			blockchains = append(blockchains, string(chain))
+		}
+	}
+	return &ankr.GetInteractionsResp{Blockchains: blockchains}, nil
+}
+
+func (s *Server) getAccountBalance(params json.RawMessage) (any, error) {
+	var req ankr.GetAccountBalanceReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	if req.WalletAddress == "" {
+		return nil, invalidParams("walletAddress is required")
+	}
+
+	onlyWhitelisted := req.OnlyWhitelisted == nil || *req.OnlyWhitelisted
+	var assets []ankr.TokenAsset
+	total := 0.0
+	for _, asset := range s.dataset.Balances {
+		if !strings.EqualFold(asset.HolderAddress, req.WalletAddress) || !chains.has(asset.Blockchain) {
+			continue
+		}
+		if _, whitelisted := s.prices[priceKey(asset.Blockchain, asset.ContractAddress)]; onlyWhitelisted && !whitelisted {
+			continue
+		}
+		assets = append(assets, asset)
+		usd, _ := strconv.ParseFloat(asset.BalanceUsd, 64)
+		total += usd
+	}
+
+	nativeFirst := req.NativeFirst == nil || *req.NativeFirst
+	slices.SortStableFunc(assets, func(a, b ankr.TokenAsset) int {
+		if nativeFirst && (a.TokenType == "NATIVE") != (b.TokenType == "NATIVE") {
+			if a.TokenType == "NATIVE" {
+				return -1
+			}
+			return 1
+		}
+		usdA, _ := strconv.ParseFloat(a.BalanceUsd, 64)
+		usdB, _ := strconv.ParseFloat(b.BalanceUsd, 64)
+		return cmp.Compare(usdB, usdA)
+	})
+	page, next, err := paginate(assets, req.PageToken, req.PageSize, allItems, allItems)
+	if err != nil {
+		return nil, err
+	}
+	return &ankr.GetAccountBalanceResp{
+		Assets:          page,
+		NextPageToken:   next,
+		TotalBalanceUsd: strconv.FormatFloat(total, 'f', 2, 64),
+	}, nil
+}
+
+func (s *Server) getCurrencies(params json.RawMessage) (any, error) {
+	var req ankr.GetCurrenciesReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := chains.single()
+	if err != nil {
+		return nil, err
+	}
+	currencies := []ankr.Currency{}
+	for _, currency := range s.dataset.Currencies {
+		if currency.Blockchain == chain {
+			currencies = append(currencies, currency)
+		}
+	}
+	return &ankr.GetCurrenciesResp{Currencies: currencies}, nil
+}
+
+// getTokenPrice answers a price of 0 for unknown tokens, as the API does
+func (s *Server) getTokenPrice(params json.RawMessage) (any, error) {
+	var req ankr.GetTokenPriceReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := chains.single()
+	if err != nil {
+		return nil, err
+	}
+	price, ok := s.prices[priceKey(chain, req.ContractAddress)]
+	if !ok {
+		price = "0"
+	}
+	return &ankr.GetTokenPriceResp{Blockchain: chain, ContractAddress: req.ContractAddress, UsdPrice: price}, nil
+}
+
+func (s *Server) getTokenHolders(params json.RawMessage) (any, error) {
+	var req ankr.GetTokenHoldersReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := chains.single()
+	if err != nil {
+		return nil, err
+	}
+	if req.ContractAddress == "" {
+		return nil, invalidParams("contractAddress is required")
+	}
+
+	resp := &ankr.GetTokenHoldersResp{Blockchain: chain, ContractAddress: req.ContractAddress}
+	var holders []ankr.TokenHolder
+	for _, asset := range s.dataset.Balances {
+		if asset.Blockchain == chain && strings.EqualFold(asset.ContractAddress, req.ContractAddress) {
+			resp.TokenDecimals = asset.TokenDecimals
+			holders = append(holders, ankr.TokenHolder{
+				Balance:           asset.Balance,
+				BalanceRawInteger: asset.BalanceRawInteger,
+				HolderAddress:     asset.HolderAddress,
+			})
+		}
+	}
+	// Largest holders first
+	slices.SortStableFunc(holders, func(a, b ankr.TokenHolder) int {
+		rawA, _ := new(big.Int).SetString(a.BalanceRawInteger, 10)
+		rawB, _ := new(big.Int).SetString(b.BalanceRawInteger, 10)
+		return rawB.Cmp(rawA)
+	})
+
+	resp.HoldersCount = int64(len(holders))
+	resp.Holders, resp.NextPageToken, err = paginate(holders, req.PageToken, req.PageSize, maxPageSize, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *Server) getTokenHoldersCount(params json.RawMessage) (any, error) {
+	var req ankr.GetTokenHoldersCountReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := chains.single()
+	if err != nil {
+		return nil, err
+	}
+	if req.ContractAddress == "" {
+		return nil, invalidParams("contractAddress is required")
+	}
+
+	resp := &ankr.GetTokenHoldersCountResp{Blockchain: chain, ContractAddress: req.ContractAddress}
+	for _, currency := range s.dataset.Currencies {
+		if currency.Blockchain == chain && strings.EqualFold(currency.Address, req.ContractAddress) {
+			resp.TokenDecimals = currency.Decimals
+		}
+	}
+	var history []ankr.HolderCountHistory
+	for _, count := range s.dataset.HolderCounts {
+		if count.Blockchain == chain && strings.EqualFold(count.ContractAddress, req.ContractAddress) {
+			history = append(history, count.HolderCountHistory)
+		}
+	}
+	// Latest first
+	slices.Reverse(history)
+	resp.HolderCountHistory, resp.NextPageToken, err = paginate(history, req.PageToken, req.PageSize, maxPageSize, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *Server) getTokenTransfers(params json.RawMessage) (any, error) {
+	var req ankr.GetTokenTransfersReq
+	chains, err := s.decodeParams(params, &req)
+	if err != nil {
+		return nil, err
+	}
+	r, err := parseRange(req.FromBlock, req.ToBlock, req.FromTimestamp, req.ToTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	var transfers []ankr.TokenTransfer
+	for _, t := range s.tokenTransfers {
+		if chains.has(t.Blockchain) && s.inRange(r, t.Blockchain, t.BlockHeight, t.Timestamp) &&
+			matchesAny(req.Address, t.FromAddress, t.ToAddress, t.ContractAddress) {
+			transfers = append(transfers, t)
+		}
+	}
+	page, next, err := paginate(ordered(transfers, req.DescOrder), req.PageToken, req.PageSize, maxPageSize, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &ankr.GetTokenTransfersResp{Transfers: page, NextPageToken: next}, nil
+}
@@ -0,0 +1,403 @@
+package ankrtest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Mode is the mode of a Recorder
+type Mode int
+
+const (
+	// ModeReplay serves requests from fixtures, without network access
+	ModeReplay Mode = iota
+
+	// ModeRecord sends requests through the real transport and writes every response to fixtures
+	ModeRecord
+)
+
+// redacted replaces secrets in recorded fixtures
+const redacted = "REDACTED"
+
+// RecorderConfig configures a Recorder
+type RecorderConfig struct {
+	// Dir is the directory of the fixture files
+	Dir string
+
+	// Mode is either ModeReplay (default) or ModeRecord
+	Mode Mode
+
+	// Transport sends requests in ModeRecord (default: http.DefaultTransport)
+	Transport http.RoundTripper
+
+	// Secrets are redacted from recorded URLs, headers and bodies
+	// The last segment of the request path, where the client puts the API key, is always redacted.
+	Secrets []string
+}
+
+// Recorder is an http.RoundTripper that records JSON-RPC calls into fixture files and replays them
+//
+// Fixtures are keyed by method and canonical params, so they don't depend on request IDs,
+// API keys or endpoints. A fixture holds every response recorded for its key, replayed in order,
+// the last one being repeated. Batches are keyed by the list of their calls.
+//
+// In ModeReplay a request without fixture fails with an *UnmatchedRequestError
+// and is reported by Unmatched.
+type Recorder struct {
+	dir       string
+	mode      Mode
+	transport http.RoundTripper
+	secrets   []string
+
+	mu        sync.Mutex
+	fixtures  map[string]*Fixture
+	replayed  map[string]int
+	unmatched []string
+}
+
+// Fixture holds the responses recorded for a method and params
+type Fixture struct {
+	// Method is the JSON-RPC method, or "batch" for batches
+	Method string `json:"method"`
+
+	// Params are the canonical params, or the list of calls of a batch
+	Params json.RawMessage `json:"params"`
+
+	// Responses are the recorded responses, in order
+	Responses []Response `json:"responses"`
+}
+
+// Response is a recorded HTTP response
+type Response struct {
+	// URL is the request URL with secrets redacted
+	URL string `json:"url"`
+
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+
+	// JSON is the body if it is valid JSON, Text otherwise
+	// Response IDs are replaced with the position of the call in the request, 0 for single calls
+	JSON json.RawMessage `json:"json,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+// UnmatchedRequestError is returned in ModeReplay for requests without fixture
+type UnmatchedRequestError struct {
+	Method string
+	Params string
+}
+
+func (e *UnmatchedRequestError) Error() string {
+	return fmt.Sprintf("ankrtest: no fixture for %s %s", e.Method, e.Params)
+}
+
+// NewRecorder creates a Recorder, loading the fixtures of config.Dir in ModeReplay
+func NewRecorder(config RecorderConfig) (*Recorder, error) {
+	r := &Recorder{
+		dir:       config.Dir,
+		mode:      config.Mode,
+		transport: config.Transport,
+		secrets:   config.Secrets,
+		fixtures:  make(map[string]*Fixture),
+		replayed:  make(map[string]int),
+	}
+	if r.transport == nil {
+		r.transport = http.DefaultTransport
+	}
+	if r.mode == ModeRecord {
+		return r, os.MkdirAll(r.dir, 0o755)
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var fixture Fixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("ankrtest: invalid fixture %s: %w", file, err)
+		}
+		r.fixtures[fixtureKey(fixture.Method, fixture.Params)] = &fixture
+	}
+	return r, nil
+}
+
+// Unmatched returns the requests that had no fixture in ModeReplay, as "method params"
+func (r *Recorder) Unmatched() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.unmatched...)
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	call, err := parseCall(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("ankrtest: %w", err)
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, reqBody, call)
+	}
+	return r.replay(req, call)
+}
+
+func (r *Recorder) record(req *http.Request, reqBody []byte, call rpcCall) (*http.Response, error) {
+	// The caller's request must not be modified, the body is forwarded with a clone
+	forwarded := req.Clone(req.Context())
+	forwarded.Body = io.NopCloser(bytes.NewReader(reqBody))
+	resp, err := r.transport.RoundTrip(forwarded)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := r.secretsOf(req)
+	recorded := Response{
+		URL:        redact(req.URL.String(), secrets),
+		StatusCode: resp.StatusCode,
+		Header:     make(http.Header, len(resp.Header)),
+	}
+	for name, values := range resp.Header {
+		for _, value := range values {
+			recorded.Header.Add(name, redact(value, secrets))
+		}
+	}
+	redactedBody := []byte(redact(string(body), secrets))
+	if normalized, ok := rewriteIDs(redactedBody, call.recordedIDs()); ok {
+		recorded.JSON = normalized
+	} else {
+		recorded.Text = string(redactedBody)
+	}
+
+	if err := r.save(call, recorded); err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// save appends a response to the fixture of call and writes it
+func (r *Recorder) save(call rpcCall, recorded Response) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := fixtureKey(call.method, call.params)
+	fixture, ok := r.fixtures[key]
+	if !ok {
+		fixture = &Fixture{Method: call.method, Params: call.params}
+		r.fixtures[key] = fixture
+	}
+	fixture.Responses = append(fixture.Responses, recorded)
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.dir, fixtureFile(call.method, key)), append(data, '\n'), 0o644)
+}
+
+func (r *Recorder) replay(req *http.Request, call rpcCall) (*http.Response, error) {
+	r.mu.Lock()
+	key := fixtureKey(call.method, call.params)
+	fixture, ok := r.fixtures[key]
+	if !ok || len(fixture.Responses) == 0 {
+		r.unmatched = append(r.unmatched, call.method+" "+string(call.params))
+		r.mu.Unlock()
+		return nil, &UnmatchedRequestError{Method: call.method, Params: string(call.params)}
+	}
+	i := min(r.replayed[key], len(fixture.Responses)-1)
+	r.replayed[key]++
+	recorded := fixture.Responses[i]
+	r.mu.Unlock()
+
+	body := []byte(recorded.Text)
+	if recorded.JSON != nil {
+		body, _ = rewriteIDs(recorded.JSON, call.replayedIDs())
+	}
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Del("Content-Length")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// secretsOf returns the secrets to redact from the exchange of req
+func (r *Recorder) secretsOf(req *http.Request) []string {
+	secrets := r.secrets
+	if key := path.Base(req.URL.Path); key != "/" && key != "." && key != "" {
+		secrets = append([]string{key}, secrets...)
+	}
+	return secrets
+}
+
+func redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return s
+}
+
+// rpcCall is the identity of a JSON-RPC request, single or batch
+type rpcCall struct {
+	method string
+	params json.RawMessage // canonical
+	ids    []json.RawMessage
+}
+
+// recordedIDs maps the request IDs to the IDs recorded in fixtures: the position of the call
+func (c rpcCall) recordedIDs() map[string]json.RawMessage {
+	ids := make(map[string]json.RawMessage, len(c.ids))
+	for i, id := range c.ids {
+		ids[string(id)] = json.RawMessage(strconv.Itoa(i))
+	}
+	return ids
+}
+
+// replayedIDs maps the IDs recorded in fixtures back to the request IDs
+func (c rpcCall) replayedIDs() map[string]json.RawMessage {
+	ids := make(map[string]json.RawMessage, len(c.ids))
+	for i, id := range c.ids {
+		ids[strconv.Itoa(i)] = id
+	}
+	return ids
+}
+
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// parseCall parses a JSON-RPC request body into its method, canonical params and IDs
+func parseCall(body []byte) (rpcCall, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var reqs []rpcRequest
+		if err := json.Unmarshal(trimmed, &reqs); err != nil {
+			return rpcCall{}, fmt.Errorf("invalid batch request: %w", err)
+		}
+		calls := make([]map[string]any, len(reqs))
+		call := rpcCall{method: "batch"}
+		for i, req := range reqs {
+			var params any
+			if len(req.Params) > 0 {
+				if err := json.Unmarshal(req.Params, &params); err != nil {
+					return rpcCall{}, fmt.Errorf("invalid params: %w", err)
+				}
+			}
+			calls[i] = map[string]any{"method": req.Method, "params": params}
+			call.ids = append(call.ids, req.ID)
+		}
+		call.params, _ = json.Marshal(calls)
+		return call, nil
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal(trimmed, &req); err != nil {
+		return rpcCall{}, fmt.Errorf("invalid request: %w", err)
+	}
+	call := rpcCall{method: req.Method, ids: []json.RawMessage{req.ID}}
+	call.params = canonicalJSON(req.Params)
+	return call, nil
+}
+
+// canonicalJSON re-encodes raw with sorted object keys and no insignificant whitespace
+func canonicalJSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return json.RawMessage("null")
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return raw
+	}
+	canonical, _ := json.Marshal(v)
+	return canonical
+}
+
+// rewriteIDs replaces the IDs of a JSON-RPC response body, a single response or a batch,
+// with their value in ids; ok is false if body isn't JSON
+func rewriteIDs(body []byte, ids map[string]json.RawMessage) (json.RawMessage, bool) {
+	var single map[string]json.RawMessage
+	if err := json.Unmarshal(body, &single); err == nil && single != nil {
+		// A single call may be answered with a null ID, so its only ID is used
+		for _, id := range ids {
+			single["id"] = id
+		}
+		out, _ := json.Marshal(single)
+		return out, true
+	}
+
+	var batch []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &batch); err == nil {
+		for _, resp := range batch {
+			if id, ok := ids[string(resp["id"])]; ok && resp != nil {
+				resp["id"] = id
+			}
+		}
+		out, _ := json.Marshal(batch)
+		return out, true
+	}
+
+	if json.Valid(body) {
+		return body, true
+	}
+	return nil, false
+}
+
+// fixtureKey identifies a fixture by method and canonical params
+func fixtureKey(method string, params json.RawMessage) string {
+	return method + " " + string(canonicalJSON(params))
+}
+
+// fixtureFile names the fixture file of key after its method and a hash of its params
+func fixtureFile(method, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("%s-%s.json", sanitize(method), hex.EncodeToString(sum[:8]))
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, s)
+}
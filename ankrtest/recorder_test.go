@@ -0,0 +1,119 @@
+package ankrtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dwdwow/ankr-go"
+)
+
+const secretKey = "secret-api-key"
+
+// TestRecorder tests recording calls against a server and replaying them offline
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.HasPrefix(string(body), "[") {
+			var reqs []struct {
+				ID int64 `json:"id"`
+			}
+			json.Unmarshal(body, &reqs)
+			// Answer out of order to check that responses are routed by ID
+			w.Write([]byte(`[{"jsonrpc":"2.0","id":` + strconv.FormatInt(reqs[1].ID, 10) + `,"result":{"currencies":[{"symbol":"BNB"}]}},` +
+				`{"jsonrpc":"2.0","id":` + strconv.FormatInt(reqs[0].ID, 10) + `,"result":{"usdPrice":"3"}}]`))
+			return
+		}
+		var req struct {
+			ID     int64                 `json:"id"`
+			Params ankr.GetTokenPriceReq `json:"params"`
+		}
+		json.Unmarshal(body, &req)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + strconv.FormatInt(req.ID, 10) + `,"result":{"usdPrice":"` + string(req.Params.Blockchain) + `-` + secretKey + `"}}`))
+	}))
+	defer server.Close()
+
+	recorder, err := NewRecorder(RecorderConfig{Dir: dir, Mode: ModeRecord})
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	client := newClient(server.URL, recorder)
+	ctx := context.Background()
+
+	recorded, err := client.GetTokenPrice(ctx, ankr.GetTokenPriceReq{Blockchain: ankr.ChainEthereum})
+	if err != nil {
+		t.Fatalf("GetTokenPrice failed: %v", err)
+	}
+	batch := client.NewBatch()
+	price := batch.GetTokenPrice(ankr.GetTokenPriceReq{Blockchain: ankr.ChainBSC})
+	currencies := batch.GetCurrencies(ankr.GetCurrenciesReq{Blockchain: ankr.ChainBSC})
+	if err := batch.Send(ctx); err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 fixture files, got %v", files)
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), secretKey) {
+			t.Errorf("Expected the API key to be redacted from %s:\n%s", file, data)
+		}
+	}
+
+	// Replay against a server that doesn't exist
+	replayer, err := NewRecorder(RecorderConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	client = newClient("http://127.0.0.1:1", replayer)
+
+	replayed, err := client.GetTokenPrice(ctx, ankr.GetTokenPriceReq{Blockchain: ankr.ChainEthereum})
+	if err != nil {
+		t.Fatalf("Replayed GetTokenPrice failed: %v", err)
+	}
+	if replayed.UsdPrice != strings.Replace(recorded.UsdPrice, secretKey, "REDACTED", 1) {
+		t.Errorf("Unexpected replayed price %q", replayed.UsdPrice)
+	}
+
+	batch = client.NewBatch()
+	replayedPrice := batch.GetTokenPrice(ankr.GetTokenPriceReq{Blockchain: ankr.ChainBSC})
+	replayedCurrencies := batch.GetCurrencies(ankr.GetCurrenciesReq{Blockchain: ankr.ChainBSC})
+	if err := batch.Send(ctx); err != nil {
+		t.Fatalf("Replayed batch failed: %v", err)
+	}
+	if replayedPrice.Result.UsdPrice != price.Result.UsdPrice ||
+		len(replayedCurrencies.Result.Currencies) != len(currencies.Result.Currencies) {
+		t.Errorf("Unexpected replayed batch results: %+v, %+v", replayedPrice, replayedCurrencies)
+	}
+
+	_, err = client.GetTokenPrice(ctx, ankr.GetTokenPriceReq{Blockchain: ankr.ChainPolygon})
+	var unmatched *UnmatchedRequestError
+	if !errors.As(err, &unmatched) || unmatched.Method != ankr.MethodGetTokenPrice {
+		t.Errorf("Expected an *UnmatchedRequestError, got %v", err)
+	}
+	if got := replayer.Unmatched(); len(got) != 1 || !strings.Contains(got[0], `"polygon"`) {
+		t.Errorf("Expected the unmatched request to be reported, got %v", got)
+	}
+}
+
+func newClient(baseURL string, transport http.RoundTripper) *ankr.HTTPClient {
+	return ankr.NewHTTPClient(&ankr.HTTPClientConfig{
+		APIKey:      secretKey,
+		BaseURL:     baseURL,
+		Transport:   transport,
+		RetryPolicy: ankr.NoRetry(),
+		Logger:      slog.New(slog.DiscardHandler),
+	})
+}
@@ -0,0 +1,553 @@
+package ankrtest
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dwdwow/ankr-go"
+)
+
+// Fault is an error or delay injected into the calls of a method by Server.Inject
+type Fault struct {
+	// Latency delays the response; the call is otherwise served normally unless another field is set
+	Latency time.Duration
+
+	// StatusCode answers with this HTTP status instead, e.g. http.StatusTooManyRequests
+	StatusCode int
+
+	// RetryAfter is sent as the Retry-After header of StatusCode responses, in whole seconds
+	RetryAfter time.Duration
+
+	// RPCError answers with this JSON-RPC error inside an HTTP 200 instead
+	RPCError *ankr.RPCRespError
+
+	// Times is the number of calls the fault applies to, 0 for every call until ClearFaults
+	Times int
+}
+
+// Server is an in-process fake of the Ankr Advanced API serving a Dataset
+//
+// It answers single and batch calls of the 16 methods wrapped by the client,
+// honoring page tokens and sizes, DescOrder, block and timestamp ranges, Address, Topics and Filter.
+// Calls without blockchain fan out to every chain of the dataset, and blockchain
+// may be a list of chains. Invalid params are answered with a -32602 RPC error.
+// Any URL path is accepted, so is any API key.
+type Server struct {
+	// URL is the base URL of the server, e.g. for HTTPClientConfig.BaseURL
+	URL string
+
+	server  *httptest.Server
+	dataset *Dataset
+
+	chains []ankr.Chain
+	latest map[string]int64
+	prices map[string]string
+
+	// Indexes in ascending order of timestamp
+	txs            []indexedTx
+	logs           []indexedLog
+	tokenTransfers []ankr.TokenTransfer
+	nftTransfers   []ankr.NFTTransfer
+
+	mu     sync.Mutex
+	faults map[string][]*Fault
+	calls  map[string]int
+}
+
+type indexedTx struct {
+	chain     string
+	block     int64
+	timestamp int64
+	tx        ankr.Tx
+}
+
+type indexedLog struct {
+	chain     string
+	block     int64
+	timestamp int64
+	log       ankr.Log
+}
+
+// NewServer starts a Server serving dataset, or NewDataset(DefaultSeed) if nil
+//
+// The dataset must not be modified afterwards. The server is stopped by Close.
+func NewServer(dataset *Dataset) *Server {
+	if dataset == nil {
+		dataset = NewDataset(DefaultSeed)
+	}
+	s := &Server{
+		dataset: dataset,
+		latest:  make(map[string]int64, len(dataset.Stats)),
+		prices:  make(map[string]string, len(dataset.Prices)),
+		faults:  make(map[string][]*Fault),
+		calls:   make(map[string]int),
+	}
+	for _, stat := range dataset.Stats {
+		s.chains = append(s.chains, ankr.Chain(stat.Blockchain))
+		s.latest[stat.Blockchain] = stat.LatestBlockNumber
+	}
+	for _, price := range dataset.Prices {
+		s.prices[priceKey(price.Blockchain, price.ContractAddress)] = price.UsdPrice
+	}
+	for _, block := range dataset.Blocks {
+		for _, tx := range block.Transactions {
+			s.txs = append(s.txs, indexedTx{block.BlockchainName, hexToInt(block.Number), hexToInt(block.Timestamp), tx})
+		}
+	}
+	slices.SortStableFunc(s.txs, func(a, b indexedTx) int { return cmp.Compare(a.timestamp, b.timestamp) })
+	for _, tx := range s.txs {
+		for _, log := range tx.tx.Logs {
+			s.logs = append(s.logs, indexedLog{tx.chain, tx.block, tx.timestamp, log})
+		}
+	}
+	s.tokenTransfers = slices.Clone(dataset.TokenTransfers)
+	slices.SortStableFunc(s.tokenTransfers, func(a, b ankr.TokenTransfer) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+	s.nftTransfers = slices.Clone(dataset.NFTTransfers)
+	slices.SortStableFunc(s.nftTransfers, func(a, b ankr.NFTTransfer) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+	return s
+}
+
+// Close stops the server
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Dataset returns the dataset served by the server
+func (s *Server) Dataset() *Dataset {
+	return s.dataset
+}
+
+// Client creates a client of the server from config, whose BaseURL and APIKey are set if empty
+func (s *Server) Client(config *ankr.HTTPClientConfig) *ankr.HTTPClient {
+	var c ankr.HTTPClientConfig
+	if config != nil {
+		c = *config
+	}
+	if c.BaseURL == "" && len(c.Endpoints) == 0 {
+		c.BaseURL = s.URL
+	}
+	if c.APIKey == "" && len(c.APIKeys) == 0 {
+		c.APIKey = "ankrtest"
+	}
+	return ankr.NewHTTPClient(&c)
+}
+
+// Inject adds a fault to the calls of method
+//
+// Faults of a method apply in the order they were injected: a fault applies to its Times calls,
+// then the next one applies. In a batch, the status code and the longest latency of its calls
+// apply to the whole batch.
+func (s *Server) Inject(method string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[method] = append(s.faults[method], &fault)
+}
+
+// ClearFaults removes the faults of every method
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.faults)
+}
+
+// Calls returns the number of calls of method received by the server, faulted ones included
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// takeFault counts a call of method and returns the fault that applies to it, nil if none
+func (s *Server) takeFault(method string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[method]++
+	faults := s.faults[method]
+	if len(faults) == 0 {
+		return nil
+	}
+	fault := *faults[0]
+	if faults[0].Times > 0 {
+		faults[0].Times--
+		if faults[0].Times == 0 {
+			s.faults[method] = faults[1:]
+		}
+	}
+	return &fault
+}
+
+type rpcResponse struct {
+	JSONRPC string             `json:"jsonrpc"`
+	ID      json.RawMessage    `json:"id"`
+	Result  any                `json:"result,omitempty"`
+	Error   *ankr.RPCRespError `json:"error,omitempty"`
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var reqs []rpcRequest
+	trimmed := bytes.TrimSpace(body)
+	batch := len(trimmed) > 0 && trimmed[0] == '['
+	if batch {
+		err = json.Unmarshal(trimmed, &reqs)
+	} else {
+		reqs = make([]rpcRequest, 1)
+		err = json.Unmarshal(trimmed, &reqs[0])
+	}
+	if err != nil {
+		writeJSON(w, rpcResponse{JSONRPC: ankr.JSONRPC, Error: &ankr.RPCRespError{Code: -32700, Message: "parse error: " + err.Error()}})
+		return
+	}
+
+	faults := make([]*Fault, len(reqs))
+	var latency time.Duration
+	var statusFault *Fault
+	for i, req := range reqs {
+		fault := s.takeFault(req.Method)
+		if fault == nil {
+			continue
+		}
+		faults[i] = fault
+		latency = max(latency, fault.Latency)
+		if fault.StatusCode != 0 && statusFault == nil {
+			statusFault = fault
+		}
+	}
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if statusFault != nil {
+		if statusFault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(statusFault.RetryAfter.Seconds()))))
+		}
+		http.Error(w, http.StatusText(statusFault.StatusCode), statusFault.StatusCode)
+		return
+	}
+
+	resps := make([]rpcResponse, len(reqs))
+	for i, req := range reqs {
+		resps[i] = s.serve(req, faults[i])
+	}
+	if batch {
+		writeJSON(w, resps)
+	} else {
+		writeJSON(w, resps[0])
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// methods are the handlers of the methods served, by name
+var methods = map[string]func(s *Server, params json.RawMessage) (any, error){
+	ankr.MethodGetNFTsByOwner:       (*Server).getNFTsByOwner,
+	ankr.MethodGetNFTMetadata:       (*Server).getNFTMetadata,
+	ankr.MethodGetNFTHolders:        (*Server).getNFTHolders,
+	ankr.MethodGetNFTTransfers:      (*Server).getNFTTransfers,
+	ankr.MethodGetBlockchainStats:   (*Server).getBlockchainStats,
+	ankr.MethodGetBlocks:            (*Server).getBlocks,
+	ankr.MethodGetLogs:              (*Server).getLogs,
+	ankr.MethodGetTxsByHash:         (*Server).getTxsByHash,
+	ankr.MethodGetTxsByAddress:      (*Server).getTxsByAddress,
+	ankr.MethodGetInteractions:      (*Server).getInteractions,
+	ankr.MethodGetAccountBalance:    (*Server).getAccountBalance,
+	ankr.MethodGetCurrencies:        (*Server).getCurrencies,
+	ankr.MethodGetTokenPrice:        (*Server).getTokenPrice,
+	ankr.MethodGetTokenHolders:      (*Server).getTokenHolders,
+	ankr.MethodGetTokenHoldersCount: (*Server).getTokenHoldersCount,
+	ankr.MethodGetTokenTransfers:    (*Server).getTokenTransfers,
+}
+
+// serve answers a call, with the RPC error of fault if it has one
+func (s *Server) serve(req rpcRequest, fault *Fault) rpcResponse {
+	resp := rpcResponse{JSONRPC: ankr.JSONRPC, ID: req.ID}
+	if fault != nil && fault.RPCError != nil {
+		resp.Error = fault.RPCError
+		return resp
+	}
+	method, ok := methods[req.Method]
+	if !ok {
+		resp.Error = &ankr.RPCRespError{Code: -32601, Message: "method not found: " + req.Method}
+		return resp
+	}
+	result, err := method(s, req.Params)
+	if err != nil {
+		resp.Error = &ankr.RPCRespError{Code: -32603, Message: err.Error()}
+		var rpcErr *rpcError
+		if errors.As(err, &rpcErr) {
+			resp.Error.Code = rpcErr.code
+		}
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+// rpcError is answered as a JSON-RPC error with its code
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e *rpcError) Error() string {
+	return e.message
+}
+
+func invalidParams(format string, args ...any) error {
+	return &rpcError{code: -32602, message: fmt.Sprintf(format, args...)}
+}
+
+// chainSet is the set of chains of a call, nil for every chain
+type chainSet []ankr.Chain
+
+func (c chainSet) has(chain string) bool {
+	return len(c) == 0 || slices.Contains(c, ankr.Chain(chain))
+}
+
+// single returns the only chain of the set, which methods without fan-out require
+func (c chainSet) single() (string, error) {
+	if len(c) != 1 {
+		return "", invalidParams("a single blockchain is required")
+	}
+	return string(c[0]), nil
+}
+
+// decodeParams decodes params into v and returns the chains of their blockchain field,
+// which may be a chain or a list of chains
+func (s *Server) decodeParams(params json.RawMessage, v any) (chainSet, error) {
+	var fields map[string]json.RawMessage
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &fields); err != nil {
+			return nil, invalidParams("invalid params: %v", err)
+		}
+	}
+
+	var chains chainSet
+	if raw, ok := fields["blockchain"]; ok {
+		var chain ankr.Chain
+		if err := json.Unmarshal(raw, &chain); err == nil {
+			if chain != "" {
+				chains = chainSet{chain}
+			}
+		} else if err := json.Unmarshal(raw, &chains); err != nil {
+			return nil, invalidParams("invalid blockchain: %s", raw)
+		}
+		delete(fields, "blockchain")
+	}
+	for _, chain := range chains {
+		if _, ok := s.latest[string(chain)]; !ok {
+			return nil, invalidParams("unsupported blockchain: %s", chain)
+		}
+	}
+
+	data, _ := json.Marshal(fields)
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, invalidParams("invalid params: %v", err)
+	}
+	return chains, nil
+}
+
+// blockRef is a block number param: a number, a decimal or hex string, "latest" or "earliest"
+type blockRef struct {
+	set    bool
+	latest bool
+	number int64
+}
+
+func parseBlockRef(v any) (blockRef, error) {
+	switch v := v.(type) {
+	case nil:
+		return blockRef{}, nil
+	case float64:
+		return blockRef{set: true, number: int64(v)}, nil
+	case int64:
+		return blockRef{set: v != 0, number: v}, nil
+	case string:
+		switch v {
+		case "":
+			return blockRef{}, nil
+		case "latest":
+			return blockRef{set: true, latest: true}, nil
+		case "earliest":
+			return blockRef{set: true}, nil
+		}
+		base, digits := 10, v
+		if hex, ok := strings.CutPrefix(v, "0x"); ok {
+			base, digits = 16, hex
+		}
+		n, err := strconv.ParseInt(digits, base, 64)
+		if err != nil {
+			return blockRef{}, invalidParams("invalid block number: %q", v)
+		}
+		return blockRef{set: true, number: n}, nil
+	}
+	return blockRef{}, invalidParams("invalid block number: %v", v)
+}
+
+func (r blockRef) resolve(latest int64) int64 {
+	if r.latest {
+		return latest
+	}
+	return r.number
+}
+
+// blockRange filters items by inclusive block and timestamp bounds, zero timestamps being unbounded
+type blockRange struct {
+	from, to                   blockRef
+	fromTimestamp, toTimestamp int64
+}
+
+func parseRange(fromBlock, toBlock any, fromTimestamp, toTimestamp int64) (blockRange, error) {
+	from, err := parseBlockRef(fromBlock)
+	if err != nil {
+		return blockRange{}, err
+	}
+	to, err := parseBlockRef(toBlock)
+	if err != nil {
+		return blockRange{}, err
+	}
+	return blockRange{from, to, fromTimestamp, toTimestamp}, nil
+}
+
+func (s *Server) inRange(r blockRange, chain string, block, timestamp int64) bool {
+	latest := s.latest[chain]
+	switch {
+	case r.from.set && block < r.from.resolve(latest),
+		r.to.set && block > r.to.resolve(latest),
+		r.fromTimestamp > 0 && timestamp < r.fromTimestamp,
+		r.toTimestamp > 0 && timestamp > r.toTimestamp:
+		return false
+	}
+	return true
+}
+
+// ordered reverses items in ascending order unless desc is false, DescOrder defaulting to true
+func ordered[T any](items []T, desc *bool) []T {
+	if desc == nil || *desc {
+		slices.Reverse(items)
+	}
+	return items
+}
+
+// Page sizes of paginated methods
+const (
+	defaultNFTsPageSize      = 10
+	maxNFTsPageSize          = 50
+	defaultHoldersPageSize   = 1000
+	defaultTransfersPageSize = 100
+	maxPageSize              = 10000
+	allItems                 = math.MaxInt32
+)
+
+// paginate returns the page of items at token, of pageSize items (defaultSize if zero, maxSize at most),
+// and the token of the next page, empty on the last page
+func paginate[T any](items []T, token string, pageSize, defaultSize, maxSize int32) ([]T, string, error) {
+	offset := 0
+	if token != "" {
+		var err error
+		if offset, err = decodePageToken(token); err != nil {
+			return nil, "", invalidParams("invalid page token: %q", token)
+		}
+	}
+	if pageSize <= 0 {
+		pageSize = defaultSize
+	}
+	pageSize = min(pageSize, maxSize)
+
+	offset = min(offset, len(items))
+	end := min(offset+int(pageSize), len(items))
+	page := append([]T{}, items[offset:end]...)
+	if end == len(items) {
+		return page, "", nil
+	}
+	return page, encodePageToken(end), nil
+}
+
+// Page tokens are opaque to clients, they encode the offset of the page
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func decodePageToken(token string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, err
+	}
+	digits, ok := strings.CutPrefix(string(data), "offset:")
+	if !ok {
+		return 0, errors.New("invalid page token")
+	}
+	offset, err := strconv.Atoi(digits)
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid page token")
+	}
+	return offset, nil
+}
+
+// matchesAny reports whether address is one of addresses, or addresses is empty
+func matchesAny(addresses []string, address ...string) bool {
+	if len(addresses) == 0 {
+		return true
+	}
+	for _, a := range addresses {
+		for _, b := range address {
+			if strings.EqualFold(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesTopics reports whether topics match filter: the i-th topic must be one of filter[i],
+// an empty filter[i] matching any topic
+func matchesTopics(filter [][]string, topics []string) bool {
+	for i, alternatives := range filter {
+		if len(alternatives) == 0 {
+			continue
+		}
+		if i >= len(topics) || !matchesAny(alternatives, topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func priceKey(chain, contract string) string {
+	return chain + "/" + strings.ToLower(contract)
+}
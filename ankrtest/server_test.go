@@ -0,0 +1,310 @@
+package ankrtest
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dwdwow/ankr-go"
+)
+
+func newServerClient(server *Server) *ankr.HTTPClient {
+	return server.Client(&ankr.HTTPClientConfig{
+		RetryPolicy: ankr.NoRetry(),
+		Logger:      slog.New(slog.DiscardHandler),
+	})
+}
+
+// collect fetches every page of pages and returns their items
+func collect[Resp ankr.PageResponse, Item any](t *testing.T, pages *ankr.Pages[Resp], items func(Resp) []Item) []Item {
+	t.Helper()
+	var all []Item
+	for pages.HasNext() {
+		page, err := pages.Next(context.Background())
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		all = append(all, items(page)...)
+	}
+	return all
+}
+
+func TestNewDatasetIsDeterministic(t *testing.T) {
+	if !reflect.DeepEqual(NewDataset(7), NewDataset(7)) {
+		t.Error("Expected the same seed to generate the same dataset")
+	}
+	if reflect.DeepEqual(NewDataset(7).Wallets, NewDataset(8).Wallets) {
+		t.Error("Expected different seeds to generate different wallets")
+	}
+}
+
+// TestServerPagination tests that paginated methods honor page sizes, tokens and DescOrder
+func TestServerPagination(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	client := newServerClient(server)
+	wallet := server.Dataset().Wallets[0]
+
+	var want []ankr.TokenTransfer
+	for _, transfer := range server.Dataset().TokenTransfers {
+		if transfer.FromAddress == wallet || transfer.ToAddress == wallet {
+			want = append(want, transfer)
+		}
+	}
+	if len(want) < 4 {
+		t.Fatalf("Expected the dataset to have several transfers of %s, got %d", wallet, len(want))
+	}
+
+	pages := client.GetTokenTransfers(ankr.GetTokenTransfersReq{Address: []string{wallet}, PageSize: 3, DescOrder: ankr.FalsePtr()})
+	got := collect(t, pages, func(page *ankr.GetTokenTransfersResp) []ankr.TokenTransfer { return page.Transfers })
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d transfers, got %d", len(want), len(got))
+	}
+	if !slices.IsSortedFunc(got, func(a, b ankr.TokenTransfer) int { return cmp.Compare(a.Timestamp, b.Timestamp) }) {
+		t.Error("Expected transfers in ascending order")
+	}
+
+	pages = client.GetTokenTransfers(ankr.GetTokenTransfersReq{Address: []string{wallet}, PageSize: 3})
+	desc := collect(t, pages, func(page *ankr.GetTokenTransfersResp) []ankr.TokenTransfer { return page.Transfers })
+	slices.Reverse(desc)
+	if !reflect.DeepEqual(desc, got) {
+		t.Error("Expected DescOrder to default to true and reverse the order")
+	}
+
+	_, err := ankr.Call[*ankr.GetLogsReq, *ankr.GetLogsResp](context.Background(), client, ankr.MethodGetLogs, &ankr.GetLogsReq{PageToken: "bogus"})
+	var rpcErr *ankr.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+		t.Errorf("Expected an invalid params error for a bogus page token, got %v", err)
+	}
+}
+
+// TestServerMultiChain tests that calls fan out to every chain, or to the listed ones
+func TestServerMultiChain(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	client := newServerClient(server)
+	ctx := context.Background()
+	wallet := server.Dataset().Wallets[1]
+
+	chainsOf := func(assets []ankr.TokenAsset) []string {
+		var chains []string
+		for _, asset := range assets {
+			if !slices.Contains(chains, asset.Blockchain) {
+				chains = append(chains, asset.Blockchain)
+			}
+		}
+		return chains
+	}
+
+	all := collect(t, client.GetAccountBalances(ankr.GetAccountBalanceReq{WalletAddress: wallet}),
+		func(page *ankr.GetAccountBalanceResp) []ankr.TokenAsset { return page.Assets })
+	if chains := chainsOf(all); len(chains) != 3 {
+		t.Errorf("Expected balances on every chain, got %v", chains)
+	}
+	if all[0].TokenType != "NATIVE" {
+		t.Errorf("Expected the native coin first, got %+v", all[0])
+	}
+	for _, asset := range all {
+		if asset.TokenSymbol == "FREE" {
+			t.Errorf("Expected tokens without price to be filtered out, got %+v", asset)
+		}
+	}
+
+	eth := collect(t, client.GetAccountBalances(ankr.GetAccountBalanceReq{WalletAddress: wallet, Blockchain: ankr.ChainEthereum}),
+		func(page *ankr.GetAccountBalanceResp) []ankr.TokenAsset { return page.Assets })
+	if chains := chainsOf(eth); !reflect.DeepEqual(chains, []string{"eth"}) {
+		t.Errorf("Expected balances on eth only, got %v", chains)
+	}
+
+	raw, err := client.CallRaw(ctx, ankr.MethodGetBlockchainStats, map[string]any{"blockchain": []string{"bsc", "polygon"}})
+	if err != nil {
+		t.Fatalf("CallRaw failed: %v", err)
+	}
+	var stats ankr.GetBlockchainStatsResp
+	json.Unmarshal(raw, &stats)
+	if len(stats.Stats) != 2 || stats.Stats[0].Blockchain != "bsc" || stats.Stats[1].Blockchain != "polygon" {
+		t.Errorf("Expected stats of the listed chains, got %+v", stats.Stats)
+	}
+
+	_, err = client.GetCurrencies(ctx, ankr.GetCurrenciesReq{Blockchain: ankr.ChainFantom})
+	var rpcErr *ankr.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+		t.Errorf("Expected an invalid params error for a chain without data, got %v", err)
+	}
+}
+
+// TestServerRanges tests block and timestamp ranges
+func TestServerRanges(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	client := newServerClient(server)
+	ctx := context.Background()
+
+	stats, err := client.GetBlockchainStats(ctx, ankr.GetBlockchainStatsReq{Blockchain: ankr.ChainEthereum})
+	if err != nil {
+		t.Fatalf("GetBlockchainStats failed: %v", err)
+	}
+	latest := stats.Stats[0].LatestBlockNumber
+
+	blocks, err := client.GetBlocks(ctx, ankr.GetBlocksReq{Blockchain: ankr.ChainEthereum, FromBlock: latest - 4, ToBlock: "latest"})
+	if err != nil {
+		t.Fatalf("GetBlocks failed: %v", err)
+	}
+	if len(blocks.Blocks) != 5 || blocks.Blocks[0].Number != hexInt(latest) {
+		t.Fatalf("Expected the last 5 blocks in descending order, got %d blocks", len(blocks.Blocks))
+	}
+	if len(blocks.Blocks[0].Transactions) == 0 || blocks.Blocks[0].Transactions[0].Logs != nil {
+		t.Errorf("Expected transactions without logs by default, got %+v", blocks.Blocks[0].Transactions)
+	}
+
+	from, to := hexToInt(blocks.Blocks[4].Timestamp), hexToInt(blocks.Blocks[1].Timestamp)
+	wallet := server.Dataset().Wallets[0]
+	want := 0
+	for _, block := range server.Dataset().Blocks {
+		ts := hexToInt(block.Timestamp)
+		for _, tx := range block.Transactions {
+			if tx.Blockchain == "eth" && ts >= from && ts <= to && (tx.From == wallet || tx.To == wallet) {
+				want++
+			}
+		}
+	}
+	txs := collect(t, client.GetTxsByAddress(ankr.GetTxsByAddressReq{
+		Address:       wallet,
+		Blockchain:    ankr.ChainEthereum,
+		FromTimestamp: from,
+		ToTimestamp:   to,
+	}), func(page *ankr.GetTxsByAddressResp) []ankr.Tx { return page.Transactions })
+	if len(txs) != want {
+		t.Errorf("Expected %d transactions, got %d", want, len(txs))
+	}
+	for _, tx := range txs {
+		if ts := hexToInt(tx.Timestamp); ts < from || ts > to {
+			t.Errorf("Expected transactions within [%d, %d], got %d", from, to, ts)
+		}
+	}
+
+	_, err = client.CallRaw(ctx, ankr.MethodGetBlocks, map[string]any{"blockchain": "eth", "fromBlock": "pending"})
+	var rpcErr *ankr.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+		t.Errorf("Expected an invalid params error for an invalid block number, got %v", err)
+	}
+}
+
+// TestServerFilters tests the Topics of GetLogs and the Filter of GetNFTsByOwner
+func TestServerFilters(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	client := newServerClient(server)
+	wallet := server.Dataset().Wallets[2]
+
+	logs := collect(t, client.GetLogs(ankr.GetLogsReq{
+		Topics:     [][]string{{TransferTopic}, {}, {"0x" + word(wallet)}},
+		DecodeLogs: true,
+	}), func(page *ankr.GetLogsResp) []ankr.Log { return page.Logs })
+	if len(logs) == 0 {
+		t.Fatal("Expected transfers to the wallet")
+	}
+	for _, log := range logs {
+		if log.Event.Inputs[1].ValueDecoded != wallet {
+			t.Errorf("Expected logs of transfers to %s, got %+v", wallet, log.Event)
+		}
+	}
+
+	var owned []ankr.NFT
+	for _, holding := range server.Dataset().NFTs {
+		if holding.Owner == wallet {
+			owned = append(owned, holding.NFT)
+		}
+	}
+	if len(owned) == 0 {
+		t.Fatal("Expected the wallet to own NFTs")
+	}
+	nfts := collect(t, client.GetNFTsByOwner(ankr.GetNFTsByOwnerReq{
+		WalletAddress: wallet,
+		Filter:        map[string][]string{owned[0].ContractAddress: {owned[0].TokenID}},
+	}), func(page *ankr.GetNFTsByOwnerResp) []ankr.NFT { return page.Assets })
+	if len(nfts) != 1 || !reflect.DeepEqual(nfts[0], owned[0]) {
+		t.Errorf("Expected only the filtered NFT, got %+v", nfts)
+	}
+}
+
+// TestServerFaults tests injected RPC errors, status codes and latency
+func TestServerFaults(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	client := newServerClient(server)
+	ctx := context.Background()
+	req := ankr.GetTokenPriceReq{Blockchain: ankr.ChainEthereum}
+
+	server.Inject(ankr.MethodGetTokenPrice, Fault{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Second, Times: 1})
+	server.Inject(ankr.MethodGetTokenPrice, Fault{RPCError: &ankr.RPCRespError{Code: -32000, Message: "boom"}, Times: 1})
+
+	_, err := client.GetTokenPrice(ctx, req)
+	if !errors.Is(err, ankr.ErrRateLimited) {
+		t.Errorf("Expected a rate limit error, got %v", err)
+	}
+	if retryAfter, ok := ankr.RetryAfter(err); !ok || retryAfter != 2*time.Second {
+		t.Errorf("Expected Retry-After of 2s, got %v", retryAfter)
+	}
+	_, err = client.GetTokenPrice(ctx, req)
+	var rpcErr *ankr.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Message != "boom" {
+		t.Errorf("Expected the injected RPC error, got %v", err)
+	}
+	price, err := client.GetTokenPrice(ctx, req)
+	if err != nil || price.UsdPrice != "3400" {
+		t.Errorf("Expected the price once faults are exhausted, got %+v, %v", price, err)
+	}
+	if calls := server.Calls(ankr.MethodGetTokenPrice); calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+
+	server.Inject(ankr.MethodGetTokenPrice, Fault{Latency: time.Second})
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetTokenPrice(timeoutCtx, req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the injected latency to exceed the deadline, got %v", err)
+	}
+	server.ClearFaults()
+	if _, err := client.GetTokenPrice(ctx, req); err != nil {
+		t.Errorf("Expected no fault after ClearFaults, got %v", err)
+	}
+}
+
+// TestServerBatch tests that batches are answered call by call
+func TestServerBatch(t *testing.T) {
+	server := NewServer(nil)
+	defer server.Close()
+	client := newServerClient(server)
+	tx := server.Dataset().Blocks[0].Transactions[0]
+
+	server.Inject(ankr.MethodGetCurrencies, Fault{RPCError: &ankr.RPCRespError{Code: -32000, Message: "boom"}})
+	batch := client.NewBatch()
+	byHash := batch.GetTxsByHash(ankr.GetTxsByHashReq{TransactionHash: tx.Hash, IncludeLogs: true})
+	currencies := batch.GetCurrencies(ankr.GetCurrenciesReq{Blockchain: ankr.ChainEthereum})
+	metadata := batch.GetNFTMetadata(ankr.GetNFTMetadataReq{
+		Blockchain:      ankr.Chain(server.Dataset().NFTs[0].NFT.Blockchain),
+		ContractAddress: server.Dataset().NFTs[0].NFT.ContractAddress,
+		TokenID:         server.Dataset().NFTs[0].NFT.TokenID,
+	})
+	if err := batch.Send(context.Background()); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if byHash.Err != nil || len(byHash.Result.Transactions) != 1 || len(byHash.Result.Transactions[0].Logs) != len(tx.Logs) {
+		t.Errorf("Expected the transaction with its logs, got %+v, %v", byHash.Result, byHash.Err)
+	}
+	if currencies.Err == nil {
+		t.Error("Expected the injected error for GetCurrencies")
+	}
+	if metadata.Err != nil || metadata.Result.Metadata.Attributes.TokenURL == "" {
+		t.Errorf("Expected the NFT metadata, got %+v, %v", metadata.Result, metadata.Err)
+	}
+}
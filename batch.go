@@ -0,0 +1,230 @@
+package ankr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Batch queues heterogeneous JSON-RPC calls and sends them as one JSON-RPC array
+//
+// Every queued call gets a unique ID, and every response of the array is routed back
+// to the BatchResult of the call with the same ID.
+// A Batch can be sent only once.
+type Batch struct {
+	client *HTTPClient
+	mu     sync.Mutex
+	calls  []batchCall
+	sent   bool
+}
+
+type batchCall struct {
+	req     RPCReqBody
+	resolve func(result json.RawMessage, err error)
+}
+
+// BatchResult holds the typed result of a queued call
+// Result and Err are set once the batch is sent
+type BatchResult[Resp any] struct {
+	Result Resp
+	Err    error
+}
+
+// NewBatch creates an empty batch sent through this client
+func (c *HTTPClient) NewBatch() *Batch {
+	return &Batch{client: c}
+}
+
+// Len returns the number of queued calls
+func (b *Batch) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.calls)
+}
+
+// AddToBatch queues a call of method with params and returns its pending result
+//
+// Default values are applied to params and they are validated immediately; if that fails,
+// the call is not queued and the returned result already holds the error.
+func AddToBatch[Req any, Resp any](b *Batch, method string, params Req) *BatchResult[Resp] {
+	result := &BatchResult[Resp]{}
+
+	newParams, err := applyDefaults(params)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to apply defaults: %w", err)
+		return result
+	}
+	if err := validate(newParams); err != nil {
+		result.Err = err
+		return result
+	}
+
+	call := batchCall{
+		req: RPCReqBody{
+			ID:      b.client.nextID.Add(1),
+			JSONRPC: JSONRPC,
+			Method:  method,
+			Params:  newParams,
+		},
+		resolve: func(raw json.RawMessage, err error) {
+			if err != nil {
+				result.Err = err
+				return
+			}
+			if err := json.Unmarshal(raw, &result.Result); err != nil {
+				result.Err = &DecodeError{Method: method, Body: raw, Err: err}
+			}
+		},
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.sent {
+		result.Err = errors.New("ankr: batch already sent")
+		return result
+	}
+	b.calls = append(b.calls, call)
+	return result
+}
+
+// Send sends all queued calls as one JSON-RPC array and resolves their results
+//
+// The returned error only reports failures of the batch as a whole,
+// such as transport or HTTP errors; it is also set on every queued result.
+// Per-call RPC errors are only reported on the matching BatchResult.
+// Batches are never retried nor cached, so only the timeout, priority and header options apply.
+func (b *Batch) Send(ctx context.Context, opts ...CallOption) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.sent {
+		return errors.New("ankr: batch already sent")
+	}
+	b.sent = true
+	if len(b.calls) == 0 {
+		return nil
+	}
+
+	done, err := b.client.lifecycle.enter()
+	if err != nil {
+		for _, call := range b.calls {
+			call.resolve(nil, err)
+		}
+		return err
+	}
+	defer done()
+
+	o := newCallOptions(opts)
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+
+	ctx, span := b.client.tracer.Start(ctx, SpanBatch, Attribute{AttrBatchSize, len(b.calls)})
+	defer span.End()
+
+	// Every call of the batch is billed, so every call is charged and takes a token of the key
+	key, _ := b.client.keys.pick(nil)
+	methods := make([]string, len(b.calls))
+	for i, call := range b.calls {
+		methods[i] = call.req.Method
+	}
+	err = b.client.breaker.rejectsAll(batchMethod, b.client.endpoints.endpoints)
+	var settle func(statusCode int)
+	if err == nil {
+		settle, err = b.client.chargeCredits(ctx, key, methods...)
+	}
+	if err == nil {
+		reqs := make([]RPCReqBody, len(b.calls))
+		for i, call := range b.calls {
+			b.client.waitLimiter(ctx, key.limiter, call.req.Method, o.priority)
+			reqs[i] = call.req
+		}
+		err = b.send(ctx, key, reqs, o.header, settle)
+	}
+	if err != nil {
+		span.RecordError(err)
+		for _, call := range b.calls {
+			call.resolve(nil, err)
+		}
+	}
+	return err
+}
+
+// send posts the batch and routes each response to its call by ID
+func (b *Batch) send(ctx context.Context, key *poolKey, reqs []RPCReqBody, header http.Header, settle func(statusCode int)) error {
+	resp, err := b.client.sendWithKey(ctx, batchMethod, key, reqs, header)
+	settle(statusOf(resp))
+	b.client.keys.observe(key, err)
+	if err != nil {
+		return err
+	}
+	body := resp.Body
+
+	var responses []RPCRespBody[json.RawMessage]
+	if err := json.Unmarshal(body, &responses); err != nil {
+		// The whole batch may be rejected with a single error object
+		var single RPCRespBody[json.RawMessage]
+		if json.Unmarshal(body, &single) == nil && single.Error != nil {
+			return newRPCError("", single.Error)
+		}
+		return &DecodeError{Body: body, Err: err}
+	}
+
+	byID := make(map[int64]RPCRespBody[json.RawMessage], len(responses))
+	for _, resp := range responses {
+		byID[resp.ID] = resp
+	}
+
+	for _, call := range b.calls {
+		resp, ok := byID[call.req.ID]
+		switch {
+		case !ok:
+			call.resolve(nil, fmt.Errorf("ankr: no response for batched call %d (%s)", call.req.ID, call.req.Method))
+		case resp.Error != nil:
+			call.resolve(nil, newRPCError(call.req.Method, resp.Error))
+		default:
+			call.resolve(resp.Result, nil)
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// Typed Batch Methods
+// ============================================================================
+
+// GetNFTMetadata queues an ankr_getNFTMetadata call
+func (b *Batch) GetNFTMetadata(req GetNFTMetadataReq) *BatchResult[*GetNFTMetadataResp] {
+	return AddToBatch[GetNFTMetadataReq, *GetNFTMetadataResp](b, MethodGetNFTMetadata, req)
+}
+
+// GetBlockchainStats queues an ankr_getBlockchainStats call
+func (b *Batch) GetBlockchainStats(req GetBlockchainStatsReq) *BatchResult[*GetBlockchainStatsResp] {
+	return AddToBatch[GetBlockchainStatsReq, *GetBlockchainStatsResp](b, MethodGetBlockchainStats, req)
+}
+
+// GetBlocks queues an ankr_getBlocks call
+func (b *Batch) GetBlocks(req GetBlocksReq) *BatchResult[*GetBlocksResp] {
+	return AddToBatch[GetBlocksReq, *GetBlocksResp](b, MethodGetBlocks, req)
+}
+
+// GetTxsByHash queues an ankr_getTransactionsByHash call
+func (b *Batch) GetTxsByHash(req GetTxsByHashReq) *BatchResult[*GetTxsByHashResp] {
+	return AddToBatch[GetTxsByHashReq, *GetTxsByHashResp](b, MethodGetTxsByHash, req)
+}
+
+// GetInteractions queues an ankr_getInteractions call
+func (b *Batch) GetInteractions(req GetInteractionsReq) *BatchResult[*GetInteractionsResp] {
+	return AddToBatch[GetInteractionsReq, *GetInteractionsResp](b, MethodGetInteractions, req)
+}
+
+// GetCurrencies queues an ankr_getCurrencies call
+func (b *Batch) GetCurrencies(req GetCurrenciesReq) *BatchResult[*GetCurrenciesResp] {
+	return AddToBatch[GetCurrenciesReq, *GetCurrenciesResp](b, MethodGetCurrencies, req)
+}
+
+// GetTokenPrice queues an ankr_getTokenPrice call
+func (b *Batch) GetTokenPrice(req GetTokenPriceReq) *BatchResult[*GetTokenPriceResp] {
+	return AddToBatch[GetTokenPriceReq, *GetTokenPriceResp](b, MethodGetTokenPrice, req)
+}
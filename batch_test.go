@@ -0,0 +1,75 @@
+package ankr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// TestBatch tests that batched calls are sent as one array and routed back by ID
+func TestBatch(t *testing.T) {
+	requests := 0
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var reqs []RPCReqBody
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			t.Errorf("failed to decode batch: %v", err)
+			return
+		}
+
+		// Answer in reverse order, failing the NFT metadata call
+		var resps []RPCRespBody[any]
+		for _, req := range slices.Backward(reqs) {
+			resp := RPCRespBody[any]{JSONRPC: JSONRPC, ID: req.ID}
+			switch req.Method {
+			case MethodGetTokenPrice:
+				params := req.Params.(map[string]any)
+				resp.Result = GetTokenPriceResp{ContractAddress: params["contractAddress"].(string), UsdPrice: "1"}
+			case MethodGetNFTMetadata:
+				resp.Error = &RPCRespError{Code: -32602, Message: "invalid params"}
+			}
+			resps = append(resps, resp)
+		}
+		json.NewEncoder(w).Encode(resps)
+	}, HTTPClientConfig{})
+
+	batch := client.NewBatch()
+	contracts := []string{
+		"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+		"0xdAC17F958D2ee523a2206206994597C13D831ec7",
+		"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+	}
+	var prices []*BatchResult[*GetTokenPriceResp]
+	for _, contract := range contracts {
+		prices = append(prices, batch.GetTokenPrice(GetTokenPriceReq{Blockchain: ChainEthereum, ContractAddress: contract}))
+	}
+	metadata := batch.GetNFTMetadata(GetNFTMetadataReq{Blockchain: ChainEthereum, ContractAddress: contracts[0], TokenID: "1"})
+
+	if batch.Len() != 4 {
+		t.Fatalf("Expected 4 queued calls, got %d", batch.Len())
+	}
+	if err := batch.Send(context.Background()); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected 1 HTTP request, got %d", requests)
+	}
+	for i, price := range prices {
+		if price.Err != nil {
+			t.Errorf("Price %d failed: %v", i, price.Err)
+			continue
+		}
+		if price.Result.ContractAddress != contracts[i] {
+			t.Errorf("Price %d routed to the wrong call: got contract %s", i, price.Result.ContractAddress)
+		}
+	}
+	if metadata.Err == nil {
+		t.Error("Expected an error for the NFT metadata call")
+	}
+	if err := batch.Send(context.Background()); err == nil {
+		t.Error("Expected an error when sending a batch twice")
+	}
+}
@@ -0,0 +1,255 @@
+package ankr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of CircuitBreakerPolicy
+const (
+	// DefaultBreakerFailureThreshold is the number of consecutive failures that open a circuit
+	DefaultBreakerFailureThreshold = 5
+
+	// DefaultBreakerOpenDuration is how long a circuit stays open before letting trial calls through
+	DefaultBreakerOpenDuration = 30 * time.Second
+
+	// DefaultBreakerHalfOpenCalls is the number of concurrent trial calls of a half-open circuit
+	DefaultBreakerHalfOpenCalls = 1
+)
+
+// ErrCircuitOpen matches errors of calls rejected by an open circuit
+var ErrCircuitOpen = errors.New("ankr: circuit open")
+
+// CircuitBreakerPolicy configures the circuit breakers of the client, one per method and endpoint
+//
+// A circuit opens after FailureThreshold consecutive 5xx responses, timeouts or network errors.
+// Calls to an open circuit fail fast with a *CircuitOpenError, or fail over to the next endpoint.
+// After OpenDuration the circuit is half-open: up to HalfOpenCalls trial calls go through,
+// a success closes the circuit and a failure opens it again.
+type CircuitBreakerPolicy struct {
+	// FailureThreshold is the number of consecutive failures that open a circuit (default: DefaultBreakerFailureThreshold)
+	FailureThreshold int
+
+	// OpenDuration is how long a circuit stays open (default: DefaultBreakerOpenDuration)
+	OpenDuration time.Duration
+
+	// HalfOpenCalls is the number of concurrent trial calls of a half-open circuit (default: DefaultBreakerHalfOpenCalls)
+	HalfOpenCalls int
+}
+
+// CircuitState is the state of a circuit
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen rejects every call
+	CircuitOpen
+
+	// CircuitHalfOpen lets a limited number of trial calls through
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitOpenError is returned when the circuits of every endpoint reject a call
+type CircuitOpenError struct {
+	// Method is the JSON-RPC method of the rejected call
+	Method string
+
+	// Endpoint is the base URL of the last endpoint that rejected the call
+	Endpoint string
+
+	// Until is when the circuit becomes half-open
+	Until time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("ankr: circuit open for %s at %s until %s", e.Method, e.Endpoint, e.Until.Format(time.RFC3339))
+}
+
+// Is makes CircuitOpenError match ErrCircuitOpen
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// circuitBreaker holds a circuit per method and endpoint
+type circuitBreaker struct {
+	threshold     int
+	openDuration  time.Duration
+	halfOpenCalls int
+
+	mu       sync.Mutex
+	circuits map[circuitKey]*circuit
+}
+
+type circuitKey struct {
+	method   string
+	endpoint string
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trials   int
+}
+
+// newCircuitBreaker creates the circuit breaker of policy, nil if policy is nil
+func newCircuitBreaker(policy *CircuitBreakerPolicy) *circuitBreaker {
+	if policy == nil {
+		return nil
+	}
+	b := &circuitBreaker{
+		threshold:     policy.FailureThreshold,
+		openDuration:  policy.OpenDuration,
+		halfOpenCalls: policy.HalfOpenCalls,
+		circuits:      make(map[circuitKey]*circuit),
+	}
+	if b.threshold <= 0 {
+		b.threshold = DefaultBreakerFailureThreshold
+	}
+	if b.openDuration <= 0 {
+		b.openDuration = DefaultBreakerOpenDuration
+	}
+	if b.halfOpenCalls <= 0 {
+		b.halfOpenCalls = DefaultBreakerHalfOpenCalls
+	}
+	return b
+}
+
+func (b *circuitBreaker) circuit(key circuitKey) *circuit {
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+// allow reports whether a call of method may be sent to endpoint
+// If not, the returned error is a *CircuitOpenError
+func (b *circuitBreaker) allow(method, endpoint string) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(circuitKey{method, endpoint})
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= b.openDuration {
+		c.state, c.trials = CircuitHalfOpen, 0
+	}
+	switch {
+	case c.state == CircuitClosed:
+		return nil
+	case c.state == CircuitHalfOpen && c.trials < b.halfOpenCalls:
+		c.trials++
+		return nil
+	}
+	return &CircuitOpenError{Method: method, Endpoint: endpoint, Until: c.openedAt.Add(b.openDuration)}
+}
+
+// rejectsAll returns a *CircuitOpenError if the circuits of method reject calls at every endpoint
+// Unlike allow, it doesn't claim trial calls of half-open circuits.
+func (b *circuitBreaker) rejectsAll(method string, endpoints []*endpoint) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var openErr error
+	for _, ep := range endpoints {
+		c, ok := b.circuits[circuitKey{method, ep.url}]
+		if !ok {
+			return nil
+		}
+		expired := c.state == CircuitOpen && time.Since(c.openedAt) >= b.openDuration
+		if c.state == CircuitClosed || expired || c.state == CircuitHalfOpen && c.trials < b.halfOpenCalls {
+			return nil
+		}
+		openErr = &CircuitOpenError{Method: method, Endpoint: ep.url, Until: c.openedAt.Add(b.openDuration)}
+	}
+	return openErr
+}
+
+// record updates the circuit of method and endpoint with the outcome of an allowed call,
+// and returns its new state if it changed
+func (b *circuitBreaker) record(method, endpoint string, err error) (state CircuitState, changed bool) {
+	if b == nil {
+		return CircuitClosed, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(circuitKey{method, endpoint})
+	before := c.state
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		// A cancelled call says nothing about the endpoint
+		c.release()
+	case isEndpointFailure(err):
+		c.failures++
+		if c.state == CircuitHalfOpen || c.failures >= b.threshold {
+			c.state, c.openedAt = CircuitOpen, time.Now()
+		}
+	default:
+		c.state, c.failures = CircuitClosed, 0
+	}
+	return c.state, c.state != before
+}
+
+// release gives back the trial call of a half-open circuit, if the call was, when it ends
+// without telling anything about the endpoint
+func (c *circuit) release() {
+	if c.state == CircuitHalfOpen {
+		c.trials--
+	}
+}
+
+// abandon releases the circuit of method and endpoint from an allowed call ended by the caller
+func (b *circuitBreaker) abandon(method, endpoint string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.circuit(circuitKey{method, endpoint}).release()
+}
+
+// state returns the current state of the circuit of method and endpoint
+func (b *circuitBreaker) state(method, endpoint string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[circuitKey{method, endpoint}]
+	if !ok {
+		return CircuitClosed
+	}
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= b.openDuration {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// CircuitState returns the state of the circuit of method at endpoint, a base URL of the client
+// Circuits are always closed when no CircuitBreakerPolicy is configured.
+func (c *HTTPClient) CircuitState(method, endpoint string) CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.state(method, strings.TrimSuffix(endpoint, "/")+"/")
+}
@@ -0,0 +1,132 @@
+package ankr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker tests that a circuit opens after repeated failures and closes after a successful trial
+func TestCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var down atomic.Bool
+	down.Store(true)
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		writeRPCResult(t, w, 1, GetTokenPriceResp{})
+	}, HTTPClientConfig{
+		CircuitBreaker: &CircuitBreakerPolicy{FailureThreshold: 2, OpenDuration: 50 * time.Millisecond},
+		RetryPolicy:    NoRetry(),
+	})
+
+	ctx := context.Background()
+	req := GetTokenPriceReq{Blockchain: ChainEthereum}
+	for range 2 {
+		if _, err := client.GetTokenPrice(ctx, req); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected the circuit to be closed, got %v", err)
+		}
+	}
+
+	_, err := client.GetTokenPrice(ctx, req)
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) || openErr.Method != MethodGetTokenPrice {
+		t.Fatalf("Expected a *CircuitOpenError, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected the open circuit to fail fast, got %d requests", calls.Load())
+	}
+	endpoint := client.EndpointStats()[0].URL
+	if state := client.CircuitState(MethodGetTokenPrice, endpoint); state != CircuitOpen {
+		t.Errorf("Expected the circuit to be open, got %v", state)
+	}
+	if state := client.CircuitState(MethodGetCurrencies, endpoint); state != CircuitClosed {
+		t.Errorf("Expected circuits of other methods to stay closed, got %v", state)
+	}
+
+	down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if state := client.CircuitState(MethodGetTokenPrice, endpoint); state != CircuitHalfOpen {
+		t.Errorf("Expected the circuit to be half-open, got %v", state)
+	}
+	if _, err := client.GetTokenPrice(ctx, req); err != nil {
+		t.Fatalf("Expected the trial call to succeed, got %v", err)
+	}
+	if state := client.CircuitState(MethodGetTokenPrice, endpoint); state != CircuitClosed {
+		t.Errorf("Expected the circuit to be closed, got %v", state)
+	}
+}
+
+// TestCircuitBreakerHalfOpen tests that half-open circuits limit trial calls and reopen on failure
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	b := newCircuitBreaker(&CircuitBreakerPolicy{FailureThreshold: 1, OpenDuration: time.Millisecond})
+	failure := &HTTPStatusError{StatusCode: http.StatusBadGateway}
+
+	b.record("m", "e", failure)
+	if err := b.allow("m", "e"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected the circuit to be open, got %v", err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if err := b.allow("m", "e"); err != nil {
+		t.Fatalf("Expected a trial call to be allowed, got %v", err)
+	}
+	if err := b.allow("m", "e"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected a single trial call, got %v", err)
+	}
+	if state, changed := b.record("m", "e", failure); state != CircuitOpen || !changed {
+		t.Errorf("Expected a failed trial to reopen the circuit, got %v", state)
+	}
+}
+
+// TestCircuitBreakerFailFast tests that calls, streamed pages and batches to open circuits
+// neither wait for the rate limiter nor spend credits
+func TestCircuitBreakerFailFast(t *testing.T) {
+	calls := map[string]func(ctx context.Context, client *HTTPClient) error{
+		"call": func(ctx context.Context, client *HTTPClient) error {
+			_, err := client.GetTokenPrice(ctx, GetTokenPriceReq{Blockchain: ChainEthereum})
+			return err
+		},
+		"stream": func(ctx context.Context, client *HTTPClient) error {
+			for _, err := range client.StreamLogs(ctx, GetLogsReq{Blockchain: ChainEthereum}) {
+				return err
+			}
+			return nil
+		},
+		"batch": func(ctx context.Context, client *HTTPClient) error {
+			batch := client.NewBatch()
+			batch.GetTokenPrice(GetTokenPriceReq{Blockchain: ChainEthereum})
+			return batch.Send(ctx)
+		},
+	}
+	for name, call := range calls {
+		client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}, HTTPClientConfig{
+			APIKeys:        []APIKey{{Key: "key", RateLimit: 1, RateLimitInterval: time.Second}},
+			CircuitBreaker: &CircuitBreakerPolicy{FailureThreshold: 1},
+			RetryPolicy:    NoRetry(),
+		})
+
+		ctx := context.Background()
+		call(ctx, client)
+		spent := client.Credits().Total
+
+		start := time.Now()
+		if err := call(ctx, client); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("%s: expected a *CircuitOpenError, got %v", name, err)
+		}
+		if waited := time.Since(start); waited > 100*time.Millisecond {
+			t.Errorf("%s: expected the call to fail fast, waited %v", name, waited)
+		}
+		if client.Credits().Total != spent {
+			t.Errorf("%s: expected the rejected call not to be charged, got %d credits", name, client.Credits().Total-spent)
+		}
+	}
+}
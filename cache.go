@@ -0,0 +1,171 @@
+package ankr
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Cache stores raw JSON-RPC response bodies
+//
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored for key, if it's present and not expired
+	Get(key string) ([]byte, bool)
+
+	// Set stores value for key, ttl is either positive or NoExpiration
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// NoExpiration is a cache TTL for responses that never change
+const NoExpiration time.Duration = -1
+
+// DefaultCacheSize is the capacity of the MemoryCache created when only CacheTTLs is configured
+const DefaultCacheSize = 10000
+
+// DefaultCacheTTLs returns the TTLs used when a Cache is configured without CacheTTLs
+//
+// Transactions by hash are cached forever, but only once all of them are confirmed.
+func DefaultCacheTTLs() map[string]time.Duration {
+	return map[string]time.Duration{
+		MethodGetCurrencies:      6 * time.Hour,
+		MethodGetNFTMetadata:     time.Hour,
+		MethodGetTokenPrice:      10 * time.Second,
+		MethodGetBlockchainStats: 10 * time.Second,
+		MethodGetTxsByHash:       NoExpiration,
+	}
+}
+
+// cacheableResult is implemented by responses that must only be cached in some states
+type cacheableResult interface {
+	cacheable() bool
+}
+
+// requestKey identifies a call by method and canonicalized params
+// Params must have defaults applied so that equivalent calls share a key
+func requestKey(method string, params any) (string, error) {
+	// Struct fields are marshaled in declaration order and map keys sorted,
+	// so the encoding is canonical
+	b, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	return method + ":" + string(b), nil
+}
+
+type cacheBypassKey struct{}
+
+// WithoutCache returns a context whose calls skip the response cache
+// Responses of these calls are still stored in the cache
+//
+// Deprecated: use WithCacheBypass, or WithCacheRefresh to keep storing responses.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+// cacheTTL returns the TTL of method, ok is false if its responses aren't cached
+func (c *HTTPClient) cacheTTL(method string) (ttl time.Duration, ok bool) {
+	if c.cache == nil {
+		return 0, false
+	}
+	ttl = c.cacheTTLs[method]
+	return ttl, ttl != 0
+}
+
+// MemoryCache is an in-memory LRU cache with per-entry TTLs
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	entries  map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // zero means no expiration
+}
+
+// NewMemoryCache creates a cache holding at most capacity entries
+// The least recently used entry is evicted when the cache is full
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity <= 0 {
+		capacity = DefaultCacheSize
+	}
+	return &MemoryCache{
+		capacity: capacity,
+		ll:       list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get implements Cache
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryCacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		m.remove(elem)
+		return nil, false
+	}
+	m.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set implements Cache
+func (m *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	var expiresAt time.Time
+	switch {
+	case ttl == NoExpiration:
+	case ttl > 0:
+		expiresAt = time.Now().Add(ttl)
+	default:
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok {
+		entry := elem.Value.(*memoryCacheEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.ll.MoveToFront(elem)
+		return
+	}
+	m.entries[key] = m.ll.PushFront(&memoryCacheEntry{key: key, value: value, expiresAt: expiresAt})
+	for m.ll.Len() > m.capacity {
+		m.remove(m.ll.Back())
+	}
+}
+
+// Delete removes key from the cache
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok {
+		m.remove(elem)
+	}
+}
+
+// Len returns the number of entries, including expired ones not evicted yet
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ll.Len()
+}
+
+func (m *MemoryCache) remove(elem *list.Element) {
+	m.ll.Remove(elem)
+	delete(m.entries, elem.Value.(*memoryCacheEntry).key)
+}
@@ -0,0 +1,118 @@
+package ankr
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestMemoryCache tests LRU eviction and TTL expiry
+func TestMemoryCache(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", []byte("1"), NoExpiration)
+	cache.Set("b", []byte("2"), NoExpiration)
+	cache.Get("a") // a is now more recently used than b
+	cache.Set("c", []byte("3"), NoExpiration)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	if v, ok := cache.Get("a"); !ok || string(v) != "1" {
+		t.Errorf("Expected a=1, got %q, %v", v, ok)
+	}
+
+	cache.Set("short", []byte("4"), 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := cache.Get("short"); ok {
+		t.Error("Expected the entry to expire")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Expected 1 entry left, got %d", cache.Len())
+	}
+}
+
+// TestResponseCache tests that configured methods are served from the cache
+func TestResponseCache(t *testing.T) {
+	var calls atomic.Int32
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeRPCResult(t, w, 1, GetCurrenciesResp{Currencies: []Currency{{Symbol: "ETH"}}})
+	}, HTTPClientConfig{
+		CacheTTLs: map[string]time.Duration{MethodGetCurrencies: time.Hour},
+	})
+
+	ctx := context.Background()
+	req := GetCurrenciesReq{Blockchain: ChainEthereum}
+	for range 3 {
+		resp, err := client.GetCurrencies(ctx, req)
+		if err != nil {
+			t.Fatalf("GetCurrencies failed: %v", err)
+		}
+		if len(resp.Currencies) != 1 {
+			t.Fatalf("Expected 1 currency, got %d", len(resp.Currencies))
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 request, got %d", calls.Load())
+	}
+
+	if _, err := client.GetCurrencies(WithoutCache(ctx), req); err != nil {
+		t.Fatalf("GetCurrencies failed: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected the bypass to send a request, got %d requests", calls.Load())
+	}
+
+	if _, err := client.GetCurrencies(ctx, GetCurrenciesReq{Blockchain: ChainBSC}); err != nil {
+		t.Fatalf("GetCurrencies failed: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected different params to miss the cache, got %d requests", calls.Load())
+	}
+}
+
+// TestResponseCacheConfirmedTxs tests that transactions are only cached once confirmed
+func TestResponseCacheConfirmedTxs(t *testing.T) {
+	var calls atomic.Int32
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		tx := Tx{Hash: "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"}
+		if calls.Add(1) > 1 {
+			tx.BlockHash, tx.BlockNumber = "0xdef", "0x10"
+		}
+		writeRPCResult(t, w, 1, GetTxsByHashResp{Transactions: []Tx{tx}})
+	}, HTTPClientConfig{Cache: NewMemoryCache(10)})
+
+	ctx := context.Background()
+	req := GetTxsByHashReq{Blockchain: ChainEthereum, TransactionHash: "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"}
+	for range 3 {
+		if _, err := client.GetTxsByHash(ctx, req); err != nil {
+			t.Fatalf("GetTxsByHash failed: %v", err)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected the pending tx not to be cached and the confirmed one to be, got %d requests", calls.Load())
+	}
+}
+
+// TestResponseCacheNullResult tests that null results are returned and not cached
+func TestResponseCacheNullResult(t *testing.T) {
+	var calls atomic.Int32
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeRPCResult(t, w, 1, nil)
+	}, HTTPClientConfig{Cache: NewMemoryCache(10)})
+
+	ctx := context.Background()
+	req := GetTxsByHashReq{Blockchain: ChainEthereum, TransactionHash: "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"}
+	for range 2 {
+		resp, err := client.GetTxsByHash(ctx, req)
+		if err != nil || resp != nil {
+			t.Fatalf("Expected a nil result, got %v, %v", resp, err)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected the null result not to be cached, got %d requests", calls.Load())
+	}
+}
@@ -0,0 +1,43 @@
+package ankr
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// PageRequest is implemented by requests of paginated methods
+//
+// Implement it on a pointer to the request type, so that Pages can advance the request in place.
+type PageRequest interface {
+	// SetPageToken sets the token of the page to fetch
+	SetPageToken(token string)
+}
+
+// PageResponse is implemented by responses of paginated methods
+type PageResponse interface {
+	// GetNextPageToken returns the token of the next page, empty on the last page
+	GetNextPageToken() string
+}
+
+// Call calls a JSON-RPC method and decodes its result into Resp
+//
+// It is meant for methods the client doesn't wrap yet. The call goes through
+// the same defaults, rate limiter, retries, middlewares and error types as the wrapped methods.
+func Call[Req any, Resp any](ctx context.Context, client *HTTPClient, method string, params Req, opts ...CallOption) (Resp, error) {
+	return postWithRetries[Req, Resp](ctx, client, method, params, opts...)
+}
+
+// CallRaw calls a JSON-RPC method and returns its undecoded result
+//
+// See Call for how the call is sent.
+func (c *HTTPClient) CallRaw(ctx context.Context, method string, params any, opts ...CallOption) (json.RawMessage, error) {
+	return postWithRetries[any, json.RawMessage](ctx, c, method, params, opts...)
+}
+
+// NewPages returns an iterator over the pages of a paginated JSON-RPC method
+//
+// req must be a pointer, it is advanced to the next page after every page fetched.
+// Pages are fetched like Call does.
+func NewPages[Req PageRequest, Resp PageResponse](client *HTTPClient, method string, req Req, opts ...CallOption) *Pages[Resp] {
+	return newPages(makeNextPageFunc[Req, Resp](client, method, req, opts))
+}
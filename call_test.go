@@ -0,0 +1,86 @@
+package ankr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// customReq is a request of a method the client doesn't wrap
+type customReq struct {
+	Blockchain Chain  `json:"blockchain"`
+	PageSize   int32  `json:"pageSize" default:"50"`
+	PageToken  string `json:"pageToken,omitempty"`
+}
+
+func (r *customReq) SetPageToken(token string) { r.PageToken = token }
+
+type customResp struct {
+	Items         []string `json:"items"`
+	NextPageToken string   `json:"nextPageToken"`
+}
+
+func (r *customResp) GetNextPageToken() string { return r.NextPageToken }
+
+// TestCall tests calling unwrapped methods with Call, CallRaw and NewPages
+func TestCall(t *testing.T) {
+	var gotReqs []RPCReqBody
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string    `json:"method"`
+			Params customReq `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		gotReqs = append(gotReqs, RPCReqBody{Method: req.Method, Params: req.Params})
+		switch {
+		case req.Method == "ankr_fail":
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`))
+		case req.Params.PageToken == "" && req.Method == "ankr_paged":
+			writeRPCResult(t, w, 1, customResp{Items: []string{"a"}, NextPageToken: "next"})
+		default:
+			writeRPCResult(t, w, 1, customResp{Items: []string{"b"}})
+		}
+	}, HTTPClientConfig{})
+
+	ctx := context.Background()
+	resp, err := Call[customReq, *customResp](ctx, client, "ankr_custom", customReq{Blockchain: ChainEthereum})
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0] != "b" {
+		t.Errorf("Unexpected result: %+v", resp)
+	}
+	if params := gotReqs[0].Params.(customReq); gotReqs[0].Method != "ankr_custom" || params.PageSize != 50 {
+		t.Errorf("Expected defaults to be applied, got %+v", gotReqs[0])
+	}
+
+	raw, err := client.CallRaw(ctx, "ankr_custom", map[string]any{"blockchain": "eth"})
+	if err != nil {
+		t.Fatalf("CallRaw failed: %v", err)
+	}
+	if string(raw) != `{"items":["b"],"nextPageToken":""}` {
+		t.Errorf("Unexpected raw result: %s", raw)
+	}
+
+	var rpcErr *RPCError
+	if _, err := client.CallRaw(ctx, "ankr_fail", customReq{}); !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Errorf("Expected an *RPCError, got %v", err)
+	}
+
+	pages := NewPages[*customReq, *customResp](client, "ankr_paged", &customReq{})
+	var items []string
+	for pages.HasNext() {
+		page, err := pages.Next(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get next page: %v", err)
+		}
+		items = append(items, page.Items...)
+	}
+	if len(items) != 2 || items[0] != "a" || items[1] != "b" {
+		t.Errorf("Expected the items of both pages, got %v", items)
+	}
+}
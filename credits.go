@@ -0,0 +1,281 @@
+package ankr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultCreditPrice is the estimated credits of a call of a method missing from HTTPClientConfig.CreditPrices
+const DefaultCreditPrice = 700
+
+// ErrBudgetExceeded matches errors of calls rejected by a hard budget
+var ErrBudgetExceeded = errors.New("ankr: budget exceeded")
+
+// Budget caps the credits spent by the calls it matches within a time window
+//
+// Method, Key and Tenant scope the budget, an empty field matching any value:
+// a budget with only Tenant set caps the spending of that tenant across methods and keys.
+type Budget struct {
+	// Limit is the maximum number of credits spent per Window
+	Limit int64
+
+	// Window is how long spending accumulates before it resets, 0 for the lifetime of the client
+	// Windows are fixed, the first one starting with the first call charged to the budget.
+	Window time.Duration
+
+	// Method is the JSON-RPC method the budget applies to
+	Method string
+
+	// Key is the API key the budget applies to
+	Key string
+
+	// Tenant is the tenant the budget applies to, see WithTenant
+	Tenant string
+
+	// Soft budgets let calls through once spent and only log a warning, once per window
+	// Calls exceeding a hard budget fail with a *BudgetExceededError without being sent.
+	Soft bool
+}
+
+// BudgetExceededError is returned for calls that would exceed a hard budget
+type BudgetExceededError struct {
+	// Budget is the exceeded budget
+	Budget Budget
+
+	// Spent is the number of credits spent in the current window
+	Spent int64
+
+	// ResetAt is when the current window ends, zero if the budget has no window
+	ResetAt time.Time
+}
+
+func (e *BudgetExceededError) Error() string {
+	msg := fmt.Sprintf("ankr: budget exceeded: %d of %d credits spent", e.Spent, e.Budget.Limit)
+	if scope := e.Budget.scope(); scope != "" {
+		msg += " for " + scope
+	}
+	if !e.ResetAt.IsZero() {
+		msg += " until " + e.ResetAt.Format(time.RFC3339)
+	}
+	return msg
+}
+
+// Is makes BudgetExceededError match ErrBudgetExceeded
+func (e *BudgetExceededError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// scope describes the calls b applies to, with the key masked
+func (b Budget) scope() string {
+	var scope string
+	add := func(name, value string) {
+		if value == "" {
+			return
+		}
+		if scope != "" {
+			scope += " "
+		}
+		scope += name + "=" + value
+	}
+	add("method", b.Method)
+	if b.Key != "" {
+		add("key", maskKey(b.Key))
+	}
+	add("tenant", b.Tenant)
+	return scope
+}
+
+// CreditUsage is a snapshot of the credits spent by the client
+//
+// Credits are estimated from the client's price table, and charged for every request
+// answered with an HTTP 200, retries included. Health probes are not charged.
+type CreditUsage struct {
+	Total int64
+
+	// ByMethod is keyed by JSON-RPC method name
+	ByMethod map[string]int64
+
+	// ByKey is keyed by API key with everything but its first 4 characters redacted
+	ByKey map[string]int64
+
+	// ByTenant is keyed by tenant, calls without tenant are left out
+	ByTenant map[string]int64
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose calls are charged to tenant, for CreditUsage and budgets
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+func tenantOf(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// creditMeter keeps the running totals of credits and enforces budgets
+type creditMeter struct {
+	prices map[string]int64
+
+	mu      sync.Mutex
+	budgets []*budgetState
+	usage   CreditUsage
+}
+
+type budgetState struct {
+	Budget
+	spent  int64
+	start  time.Time
+	warned bool
+}
+
+func newCreditMeter(config *HTTPClientConfig) *creditMeter {
+	m := &creditMeter{
+		prices: maps.Clone(config.CreditPrices),
+		usage: CreditUsage{
+			ByMethod: make(map[string]int64),
+			ByKey:    make(map[string]int64),
+			ByTenant: make(map[string]int64),
+		},
+	}
+	for _, budget := range config.Budgets {
+		m.budgets = append(m.budgets, &budgetState{Budget: budget})
+	}
+	return m
+}
+
+func (m *creditMeter) price(method string) int64 {
+	if price, ok := m.prices[method]; ok {
+		return price
+	}
+	return DefaultCreditPrice
+}
+
+// charge charges the credits of calls of methods with key and tenant to the totals and matching budgets
+//
+// If a hard budget would be exceeded, nothing is charged and a *BudgetExceededError is returned.
+// The soft budgets exceeded for the first time in their window are returned.
+func (m *creditMeter) charge(methods []string, key, tenant string) (exceeded []Budget, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	costs := make([]int64, len(m.budgets))
+	for i, b := range m.budgets {
+		if b.Window > 0 && !b.start.IsZero() && now.Sub(b.start) >= b.Window {
+			b.spent, b.start, b.warned = 0, time.Time{}, false
+		}
+		costs[i] = b.cost(m, methods, key, tenant)
+		if costs[i] == 0 || b.Soft || b.spent+costs[i] <= b.Limit {
+			continue
+		}
+		budgetErr := &BudgetExceededError{Budget: b.Budget, Spent: b.spent}
+		if b.Window > 0 && !b.start.IsZero() {
+			budgetErr.ResetAt = b.start.Add(b.Window)
+		}
+		return nil, budgetErr
+	}
+
+	for i, b := range m.budgets {
+		if costs[i] == 0 {
+			continue
+		}
+		if b.start.IsZero() {
+			b.start = now
+		}
+		b.spent += costs[i]
+		if b.Soft && b.spent > b.Limit && !b.warned {
+			b.warned = true
+			exceeded = append(exceeded, b.Budget)
+		}
+	}
+	m.add(methods, key, tenant, 1)
+	return exceeded, nil
+}
+
+// refund gives back the credits charged for calls of methods that weren't answered
+func (m *creditMeter) refund(methods []string, key, tenant string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, b := range m.budgets {
+		b.spent = max(b.spent-b.cost(m, methods, key, tenant), 0)
+	}
+	m.add(methods, key, tenant, -1)
+}
+
+// add adds sign times the credits of calls of methods to the totals
+func (m *creditMeter) add(methods []string, key, tenant string, sign int64) {
+	for _, method := range methods {
+		credits := sign * m.price(method)
+		m.usage.Total += credits
+		m.usage.ByMethod[method] += credits
+		m.usage.ByKey[maskKey(key)] += credits
+		if tenant != "" {
+			m.usage.ByTenant[tenant] += credits
+		}
+	}
+}
+
+// cost returns the credits of the calls of methods matched by b
+func (b *budgetState) cost(m *creditMeter, methods []string, key, tenant string) int64 {
+	if b.Key != "" && b.Key != key || b.Tenant != "" && b.Tenant != tenant {
+		return 0
+	}
+	var cost int64
+	for _, method := range methods {
+		if b.Method == "" || b.Method == method {
+			cost += m.price(method)
+		}
+	}
+	return cost
+}
+
+func (m *creditMeter) snapshot() CreditUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return CreditUsage{
+		Total:    m.usage.Total,
+		ByMethod: maps.Clone(m.usage.ByMethod),
+		ByKey:    maps.Clone(m.usage.ByKey),
+		ByTenant: maps.Clone(m.usage.ByTenant),
+	}
+}
+
+// chargeCredits charges the calls of methods sent with key on behalf of the tenant of ctx
+//
+// The returned settle function must be called with the response status code, or 0 without response,
+// to refund the credits of requests that weren't answered with an HTTP 200.
+func (c *HTTPClient) chargeCredits(ctx context.Context, key *poolKey, methods ...string) (settle func(statusCode int), err error) {
+	tenant := tenantOf(ctx)
+	exceeded, err := c.credits.charge(methods, key.key, tenant)
+	if err != nil {
+		return nil, err
+	}
+	for _, budget := range exceeded {
+		c.logger.WarnContext(ctx, "ankr: soft budget exceeded", "limit", budget.Limit, "window", budget.Window, "scope", budget.scope())
+	}
+	return func(statusCode int) {
+		if statusCode != http.StatusOK {
+			c.credits.refund(methods, key.key, tenant)
+		}
+	}, nil
+}
+
+// statusOf returns the status code of resp, 0 if it is nil
+func statusOf(resp *RPCResponse) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+// Credits returns the estimated credits spent by the client
+func (c *HTTPClient) Credits() CreditUsage {
+	return c.credits.snapshot()
+}
@@ -0,0 +1,154 @@
+package ankr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// creditServer answers every call, single or batched, with an empty result
+// and fails calls to keys listed in failing with a 500
+func creditServer(t *testing.T, calls map[string]int, mu *sync.Mutex, failing ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		mu.Lock()
+		calls[key]++
+		mu.Unlock()
+		for _, k := range failing {
+			if k == key {
+				http.Error(w, "boom", http.StatusInternalServerError)
+				return
+			}
+		}
+		body, _ := io.ReadAll(r.Body)
+		if strings.HasPrefix(string(body), "[") {
+			var reqs []RPCReqBody
+			json.Unmarshal(body, &reqs)
+			resps := make([]RPCRespBody[any], len(reqs))
+			for i, req := range reqs {
+				resps[i] = RPCRespBody[any]{JSONRPC: JSONRPC, ID: req.ID, Result: map[string]any{}}
+			}
+			json.NewEncoder(w).Encode(resps)
+			return
+		}
+		writeRPCResult(t, w, 1, map[string]any{})
+	}
+}
+
+// TestCredits tests the running totals of credits per method, key and tenant
+func TestCredits(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	client := newStandInClient(t, creditServer(t, calls, &mu, "key-down"), HTTPClientConfig{
+		APIKeys:      []APIKey{{Key: "key-up"}, {Key: "key-down"}},
+		CreditPrices: map[string]int64{MethodGetTokenPrice: 10},
+		RetryPolicy:  NoRetry(),
+	})
+	ctx := WithTenant(context.Background(), "acme")
+
+	if _, err := client.GetTokenPrice(ctx, GetTokenPriceReq{Blockchain: ChainEthereum}); err != nil {
+		t.Fatalf("GetTokenPrice failed: %v", err)
+	}
+	// Failed requests are refunded
+	if _, err := client.GetTokenPrice(ctx, GetTokenPriceReq{Blockchain: ChainEthereum}); err == nil {
+		t.Fatal("Expected the call on the failing key to fail")
+	}
+
+	batch := client.NewBatch()
+	batch.GetTokenPrice(GetTokenPriceReq{Blockchain: ChainBSC})
+	batch.GetCurrencies(GetCurrenciesReq{Blockchain: ChainBSC})
+	if err := batch.Send(context.Background()); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	usage := client.Credits()
+	want := int64(10 + 10 + DefaultCreditPrice)
+	if usage.Total != want {
+		t.Errorf("Expected %d credits in total, got %d", want, usage.Total)
+	}
+	if usage.ByMethod[MethodGetTokenPrice] != 20 || usage.ByMethod[MethodGetCurrencies] != DefaultCreditPrice {
+		t.Errorf("Unexpected credits per method: %v", usage.ByMethod)
+	}
+	if usage.ByKey["key-...REDACTED"] != want {
+		t.Errorf("Expected every credit on the healthy key, got %v", usage.ByKey)
+	}
+	if len(usage.ByTenant) != 1 || usage.ByTenant["acme"] != 10 {
+		t.Errorf("Expected only the single call to be charged to the tenant, got %v", usage.ByTenant)
+	}
+}
+
+// TestHardBudget tests that calls over a hard budget fail without being sent until the window ends
+func TestHardBudget(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	client := newStandInClient(t, creditServer(t, calls, &mu), HTTPClientConfig{
+		CreditPrices: map[string]int64{MethodGetTokenPrice: 10},
+		Budgets:      []Budget{{Limit: 25, Window: 200 * time.Millisecond, Method: MethodGetTokenPrice, Tenant: "acme"}},
+	})
+	ctx := WithTenant(context.Background(), "acme")
+	req := GetTokenPriceReq{Blockchain: ChainEthereum}
+
+	for range 2 {
+		if _, err := client.GetTokenPrice(ctx, req); err != nil {
+			t.Fatalf("GetTokenPrice failed: %v", err)
+		}
+	}
+	_, err := client.GetTokenPrice(ctx, req)
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) || !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Expected a budget error, got %v", err)
+	}
+	if budgetErr.Spent != 20 || budgetErr.ResetAt.IsZero() {
+		t.Errorf("Unexpected budget error: %+v", budgetErr)
+	}
+	if calls["test-key"] != 2 {
+		t.Errorf("Expected the rejected call not to be sent nor retried, got %d requests", calls["test-key"])
+	}
+
+	// Other tenants and methods are not capped
+	if _, err := client.GetTokenPrice(context.Background(), req); err != nil {
+		t.Errorf("Expected calls without tenant to go through, got %v", err)
+	}
+	if _, err := client.GetCurrencies(ctx, GetCurrenciesReq{Blockchain: ChainEthereum}); err != nil {
+		t.Errorf("Expected calls of other methods to go through, got %v", err)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	if _, err := client.GetTokenPrice(ctx, req); err != nil {
+		t.Errorf("Expected the budget to reset with its window, got %v", err)
+	}
+}
+
+// TestKeyBudget tests that calls move on to the next key once the budget of a key is spent,
+// and that soft budgets only warn
+func TestKeyBudget(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	client := newStandInClient(t, creditServer(t, calls, &mu), HTTPClientConfig{
+		APIKeys:      []APIKey{{Key: "key-a"}, {Key: "key-b"}},
+		CreditPrices: map[string]int64{MethodGetTokenPrice: 10},
+		Budgets: []Budget{
+			{Limit: 10, Key: "key-a"},
+			{Limit: 10, Soft: true},
+		},
+		RetryPolicy: NoRetry(),
+	})
+
+	for range 4 {
+		if _, err := client.GetTokenPrice(context.Background(), GetTokenPriceReq{Blockchain: ChainEthereum}); err != nil {
+			t.Fatalf("GetTokenPrice failed: %v", err)
+		}
+	}
+	if calls["key-a"] != 1 || calls["key-b"] != 3 {
+		t.Errorf("Expected key-a to be used once, got %v", calls)
+	}
+	if total := client.Credits().Total; total != 40 {
+		t.Errorf("Expected soft budgets to let calls through, got %d credits", total)
+	}
+}
@@ -0,0 +1,147 @@
+package ankr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugKeyVariable is the shell variable standing for the API key in debug curl commands
+const DebugKeyVariable = "ANKR_API_KEY"
+
+// debugger writes the dumps of HTTPClientConfig.Debug, one whole dump at a time
+type debugger struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// newDebugger creates the debugger of config, nil if debug mode is off
+func newDebugger(config *HTTPClientConfig) *debugger {
+	if !config.Debug {
+		return nil
+	}
+	out := config.DebugOutput
+	if out == nil {
+		out = os.Stderr
+	}
+	return &debugger{out: out}
+}
+
+func (d *debugger) write(dump string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	io.WriteString(d.out, dump)
+}
+
+// debugDump is the dump of a single HTTP request, written once its response body is closed
+type debugDump struct {
+	client *HTTPClient
+	buf    strings.Builder
+	start  time.Time
+}
+
+// debugRequest starts the dump of req, whose body is payload marshaled to body
+// It returns nil if debug mode is off.
+func (c *HTTPClient) debugRequest(req *http.Request, payload any, body []byte) *debugDump {
+	if c.debugger == nil {
+		return nil
+	}
+	d := &debugDump{client: c, start: time.Now()}
+
+	var params any
+	switch p := payload.(type) {
+	case RPCReqBody:
+		fmt.Fprintf(&d.buf, "ankr: debug: %s\n", p.Method)
+		params = p.Params
+	case []RPCReqBody:
+		fmt.Fprintf(&d.buf, "ankr: debug: batch of %d calls\n", len(p))
+		params = p
+	default:
+		fmt.Fprintf(&d.buf, "ankr: debug: %T\n", payload)
+		params = payload
+	}
+
+	// The key is replaced by a shell variable, so that the command runs as is once it is exported
+	uri := req.URL.String()
+	for _, k := range c.keys.keys {
+		if k.key != "" {
+			uri = strings.ReplaceAll(uri, k.key, "${"+DebugKeyVariable+"}")
+		}
+	}
+	fmt.Fprintf(&d.buf, "curl -X %s %q", req.Method, uri)
+	for _, name := range slices.Sorted(maps.Keys(req.Header)) {
+		for _, value := range req.Header[name] {
+			fmt.Fprintf(&d.buf, " \\\n  -H %s", shellQuote(name+": "+c.redact(value)))
+		}
+	}
+	fmt.Fprintf(&d.buf, " \\\n  --data-raw %s\n", shellQuote(c.redact(string(body))))
+
+	if indented, err := json.MarshalIndent(params, "", "  "); err == nil {
+		fmt.Fprintf(&d.buf, "params: %s\n", c.redact(string(indented)))
+	}
+	return d
+}
+
+// response completes the dump with the response or error of the request
+// The body of resp is wrapped so that the dump is written once it is closed.
+func (d *debugDump) response(resp *http.Response, err error) {
+	if d == nil {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(&d.buf, "error after %v: %v\n\n", time.Since(d.start).Round(time.Microsecond), err)
+		d.client.debugger.write(d.buf.String())
+		return
+	}
+	fmt.Fprintf(&d.buf, "status: %s in %v\n", resp.Status, time.Since(d.start).Round(time.Microsecond))
+	resp.Body = &debugBody{ReadCloser: resp.Body, dump: d}
+}
+
+// debugBody keeps the head of a response body for its dump
+type debugBody struct {
+	io.ReadCloser
+	dump    *debugDump
+	head    []byte
+	size    int
+	readErr error
+	once    sync.Once
+}
+
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += n
+	if keep := min(n, maxErrorBodyLen+1-len(b.head)); keep > 0 {
+		b.head = append(b.head, p[:keep]...)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		b.readErr = err
+	}
+	return n, err
+}
+
+func (b *debugBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		d := b.dump
+		fmt.Fprintf(&d.buf, "body: %d bytes read in %v: %s\n", b.size, time.Since(d.start).Round(time.Microsecond), d.client.redact(truncateBody(b.head)))
+		if b.readErr != nil {
+			fmt.Fprintf(&d.buf, "body error: %v\n", b.readErr)
+		}
+		d.buf.WriteString("\n")
+		d.client.debugger.write(d.buf.String())
+	})
+	return err
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,69 @@
+package ankr
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestDebug tests that debug mode dumps requests as curl commands without the API key
+func TestDebug(t *testing.T) {
+	const apiKey = "super-secret-key"
+	var out bytes.Buffer
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeRPCResult(t, w, 1, GetTokenPriceResp{Blockchain: "eth", UsdPrice: strings.Repeat("9", 1000)})
+	}, HTTPClientConfig{APIKey: apiKey, Debug: true, DebugOutput: &out})
+
+	_, err := client.GetTokenPrice(context.Background(), GetTokenPriceReq{Blockchain: ChainEthereum}, WithHeader("X-Job", "it's a test"))
+	if err != nil {
+		t.Fatalf("GetTokenPrice failed: %v", err)
+	}
+	if _, err := client.GetNFTsByOwner(GetNFTsByOwnerReq{WalletAddress: "vitalik.eth"}).Next(context.Background()); err != nil {
+		t.Fatalf("GetNFTsByOwner failed: %v", err)
+	}
+
+	dump := out.String()
+	if strings.Contains(dump, apiKey) {
+		t.Errorf("Expected the API key to be masked, got %q", dump)
+	}
+	for _, want := range []string{
+		"ankr: debug: " + MethodGetTokenPrice,
+		`curl -X POST "` + client.endpoints.endpoints[0].url + "${ANKR_API_KEY}\"",
+		`-H 'X-Job: it'\''s a test'`,
+		`--data-raw '{"id":1,"jsonrpc":"2.0","method":"ankr_getTokenPrice","params":{"blockchain":"eth"}}'`,
+		"params: {\n  \"blockchain\": \"eth\"\n}",
+		"ankr: debug: " + MethodGetNFTsByOwner,
+		`"pageSize": 50`,
+		"status: 200 OK in ",
+		`"usdPrice":"999`,
+		"...(truncated)",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("Expected the dump to contain %q, got:\n%s", want, dump)
+		}
+	}
+}
+
+// TestDebugError tests that requests failing without response are dumped with their error
+func TestDebugError(t *testing.T) {
+	server := httptest.NewServer(nil)
+	server.Close()
+
+	var out bytes.Buffer
+	client := NewHTTPClient(&HTTPClientConfig{
+		APIKey:      "super-secret-key",
+		BaseURL:     server.URL,
+		RetryPolicy: NoRetry(),
+		Debug:       true,
+		DebugOutput: &out,
+	})
+	if _, err := client.GetCurrencies(context.Background(), GetCurrenciesReq{Blockchain: ChainEthereum}); err == nil {
+		t.Fatal("Expected the request to fail")
+	}
+	if dump := out.String(); !strings.Contains(dump, "error after") || strings.Contains(dump, "super-secret-key") {
+		t.Errorf("Expected the redacted error in the dump, got %q", dump)
+	}
+}
@@ -0,0 +1,71 @@
+package ankr
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup collapses identical concurrent calls into a single request
+//
+// The shared request runs on a context detached from any single caller.
+// It is only cancelled once every caller waiting for it has given up,
+// so a caller cancelling its own context doesn't fail the others.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	resp    *RPCResponse
+	err     error
+}
+
+// do calls fn once for all concurrent callers with the same key and returns its result to each of them
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (*RPCResponse, error)) (*RPCResponse, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	f, ok := g.flights[key]
+	if ok {
+		f.waiters++
+	} else {
+		flightCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel, waiters: 1}
+		g.flights[key] = f
+		go g.run(flightCtx, key, f, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.resp, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Nobody is interested in the result anymore
+			f.cancel()
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, f *flight, fn func(ctx context.Context) (*RPCResponse, error)) {
+	defer f.cancel()
+	f.resp, f.err = fn(ctx)
+
+	g.mu.Lock()
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+	g.mu.Unlock()
+	close(f.done)
+}
@@ -0,0 +1,101 @@
+package ankr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDeduplicateRequests tests that identical concurrent calls share a single request
+func TestDeduplicateRequests(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		writeRPCResult(t, w, 1, GetTokenPriceResp{UsdPrice: "1.5"})
+	}, HTTPClientConfig{DeduplicateRequests: true})
+
+	req := GetTokenPriceReq{Blockchain: ChainEthereum}
+	cancelled, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		ctx := context.Background()
+		if i == 0 {
+			ctx = cancelled
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.GetTokenPrice(ctx, req)
+			if err == nil && resp.UsdPrice != "1.5" {
+				t.Errorf("Unexpected price %q", resp.UsdPrice)
+			}
+			errs[i] = err
+		}()
+	}
+
+	// Let every caller join the flight, then give up on one of them
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("Expected the cancelled caller to fail with context.Canceled, got %v", errs[0])
+	}
+	for i, err := range errs[1:] {
+		if err != nil {
+			t.Errorf("Caller %d failed: %v", i+1, err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 request, got %d", calls.Load())
+	}
+
+	if _, err := client.GetTokenPrice(context.Background(), req); err != nil {
+		t.Fatalf("GetTokenPrice failed: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected a finished flight not to be reused, got %d requests", calls.Load())
+	}
+}
+
+// TestDeduplicateRequestsTenants tests that identical calls of different tenants aren't merged
+func TestDeduplicateRequestsTenants(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		writeRPCResult(t, w, 1, GetTokenPriceResp{UsdPrice: "1.5"})
+	}, HTTPClientConfig{DeduplicateRequests: true})
+
+	var wg sync.WaitGroup
+	for _, tenant := range []string{"acme", "globex"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetTokenPrice(WithTenant(context.Background(), tenant), GetTokenPriceReq{Blockchain: ChainEthereum}); err != nil {
+				t.Errorf("GetTokenPrice failed: %v", err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 2 {
+		t.Errorf("Expected a request per tenant, got %d", calls.Load())
+	}
+	if usage := client.Credits(); usage.ByTenant["acme"] == 0 || usage.ByTenant["globex"] == 0 {
+		t.Errorf("Expected both tenants to be charged, got %v", usage.ByTenant)
+	}
+}
@@ -100,3 +100,23 @@ func setFieldValue(field reflect.Value, value string) error {
 
 	return nil
 }
+
+// applyDefaults applies default values to params if it is a struct or a pointer to one
+// Other params, such as maps or raw JSON, are returned as is
+func applyDefaults[T any](params T) (T, error) {
+	v := reflect.ValueOf(params)
+	switch {
+	case v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Struct:
+		return ApplyDefaults(params)
+	case v.Kind() != reflect.Struct:
+		return params, nil
+	}
+
+	// T may be an interface type, so the struct is copied to be settable
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	if _, err := ApplyDefaults(ptr.Interface()); err != nil {
+		return params, err
+	}
+	return ptr.Elem().Interface().(T), nil
+}
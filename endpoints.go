@@ -0,0 +1,365 @@
+package ankr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultEndpointFailureThreshold is the number of consecutive failures that mark an endpoint unhealthy
+const DefaultEndpointFailureThreshold = 3
+
+// DefaultHealthCheckInterval is the minimum time between two probes of an unhealthy endpoint
+const DefaultHealthCheckInterval = 30 * time.Second
+
+// DefaultHealthCheckChain is the chain of the GetBlockchainStats calls probing unhealthy endpoints
+const DefaultHealthCheckChain = ChainEthereum
+
+// healthCheckTimeout bounds a single probe of an unhealthy endpoint
+const healthCheckTimeout = 10 * time.Second
+
+// EndpointStats is a snapshot of the health of an endpoint
+type EndpointStats struct {
+	// URL is the base URL of the endpoint, without API key
+	URL string
+
+	// Healthy is false once the endpoint failed too many times in a row, until a probe succeeds
+	Healthy bool
+
+	// Requests is the number of requests sent to the endpoint, probes excluded
+	Requests int64
+
+	// Failures is the number of requests that failed with a 5xx response, a timeout or a network error
+	Failures int64
+
+	// ConsecutiveFailures is the number of failures since the last success
+	ConsecutiveFailures int
+
+	// LastProbe is when the endpoint was last probed, zero if it never was
+	LastProbe time.Time
+}
+
+// endpointPool holds the ordered endpoints of a client and tracks their health
+//
+// Health is checked passively from the outcome of every request: an endpoint
+// failing threshold times in a row is marked unhealthy and calls fail over to the next one.
+// Unhealthy endpoints are actively probed with a cheap GetBlockchainStats call,
+// at most once per interval, and calls fail back to them once a probe succeeds.
+type endpointPool struct {
+	endpoints []*endpoint
+	threshold int
+	interval  time.Duration
+	chain     Chain
+}
+
+type endpoint struct {
+	url string
+
+	requests atomic.Int64
+	failures atomic.Int64
+	probing  atomic.Bool
+
+	mu                  sync.Mutex
+	healthy             bool
+	consecutiveFailures int
+	lastProbe           time.Time
+}
+
+// newEndpointPool creates the endpoint pool of config
+func newEndpointPool(config *HTTPClientConfig) *endpointPool {
+	urls := config.Endpoints
+	if config.BaseURL != "" || len(urls) == 0 {
+		baseURL := config.BaseURL
+		if baseURL == "" {
+			baseURL = DefaultBaseURL
+		}
+		urls = append([]string{baseURL}, urls...)
+	}
+
+	pool := &endpointPool{
+		threshold: config.EndpointFailureThreshold,
+		interval:  config.HealthCheckInterval,
+		chain:     config.HealthCheckChain,
+	}
+	if pool.threshold <= 0 {
+		pool.threshold = DefaultEndpointFailureThreshold
+	}
+	if pool.interval <= 0 {
+		pool.interval = DefaultHealthCheckInterval
+	}
+	if pool.chain == "" {
+		pool.chain = DefaultHealthCheckChain
+	}
+	for _, url := range urls {
+		pool.endpoints = append(pool.endpoints, &endpoint{
+			url:     strings.TrimSuffix(url, "/") + "/",
+			healthy: true,
+		})
+	}
+	return pool
+}
+
+// ordered returns the healthy endpoints in configuration order followed by the unhealthy ones
+func (p *endpointPool) ordered() []*endpoint {
+	if len(p.endpoints) == 1 {
+		return p.endpoints
+	}
+	ordered := make([]*endpoint, 0, len(p.endpoints))
+	var unhealthy []*endpoint
+	for _, ep := range p.endpoints {
+		if ep.isHealthy() {
+			ordered = append(ordered, ep)
+		} else {
+			unhealthy = append(unhealthy, ep)
+		}
+	}
+	return append(ordered, unhealthy...)
+}
+
+// observe updates the health of ep from the outcome of a request
+// and reports whether ep was just marked unhealthy
+func (p *endpointPool) observe(ep *endpoint, err error) (markedUnhealthy bool) {
+	failed := isEndpointFailure(err)
+	if failed {
+		ep.failures.Add(1)
+	}
+
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if !failed {
+		ep.consecutiveFailures = 0
+		return false
+	}
+	ep.consecutiveFailures++
+	if ep.healthy && ep.consecutiveFailures >= p.threshold {
+		ep.healthy = false
+		return true
+	}
+	return false
+}
+
+// isEndpointFailure reports whether err is caused by the endpoint rather than the call:
+// 5xx responses, timeouts and network errors
+// It must not be given the errors of calls whose context is done.
+func isEndpointFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	var rpcErr *RPCError
+	var decodeErr *DecodeError
+	var sizeErr *ResponseTooLargeError
+	var openErr *CircuitOpenError
+	return !errors.As(err, &rpcErr) && !errors.As(err, &decodeErr) && !errors.As(err, &sizeErr) && !errors.As(err, &openErr)
+}
+
+func (ep *endpoint) isHealthy() bool {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return ep.healthy
+}
+
+// shouldProbe reports whether an unhealthy endpoint is due for a probe, and claims it if so
+func (p *endpointPool) shouldProbe(ep *endpoint) bool {
+	if len(p.endpoints) == 1 {
+		// There is nothing to fail over to, requests keep probing the endpoint
+		return false
+	}
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if ep.healthy || time.Since(ep.lastProbe) < p.interval || !ep.probing.CompareAndSwap(false, true) {
+		return false
+	}
+	ep.lastProbe = time.Now()
+	return true
+}
+
+func (ep *endpoint) stats() EndpointStats {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return EndpointStats{
+		URL:                 ep.url,
+		Healthy:             ep.healthy,
+		Requests:            ep.requests.Load(),
+		Failures:            ep.failures.Load(),
+		ConsecutiveFailures: ep.consecutiveFailures,
+		LastProbe:           ep.lastProbe,
+	}
+}
+
+// sendWithFailover sends payload with key to the healthiest endpoint,
+// and to the next ones while endpoints fail with 5xx responses, timeouts or network errors
+//
+// Every request sent is reported to the client's metrics. The JSON-RPC error of a single call
+// is returned as an *RPCError, along with the response.
+func (c *HTTPClient) sendWithFailover(ctx context.Context, method string, key *poolKey, payload any, header http.Header) (*RPCResponse, error) {
+	_, single := payload.(RPCReqBody)
+	var resp *RPCResponse
+	err := c.withFailover(ctx, method, key, func(ctx context.Context, ep *endpoint) (err error) {
+		start := time.Now()
+		resp, err = c.send(ctx, ep.url+key.key, payload, header)
+		if err == nil && single {
+			err = checkRPCError(method, resp)
+		}
+		c.observeRequest(method, start, resp, err)
+		return err
+	})
+	return resp, err
+}
+
+var (
+	// errCallTimeout is the cause of the deadlines set from MethodTimeouts and WithTimeout,
+	// endpoints still running when they pass are timing out
+	errCallTimeout = fmt.Errorf("ankr: call timed out: %w", context.DeadlineExceeded)
+
+	// errAttemptTimeout fails an endpoint attempt that used up its share of the call's deadline
+	errAttemptTimeout = fmt.Errorf("ankr: endpoint timed out: %w", context.DeadlineExceeded)
+)
+
+// withFailover calls send with the healthiest endpoint, and with the next ones while it fails
+// with 5xx responses, timeouts or network errors
+//
+// When ctx has a deadline, each attempt gets an even share of the time left between the endpoints left,
+// so that a hanging endpoint times out while there is still time to fail over.
+// Endpoints whose circuit for method is open are skipped; if every one is,
+// the call fails fast with a *CircuitOpenError.
+// key is only used to probe unhealthy endpoints.
+func (c *HTTPClient) withFailover(ctx context.Context, method string, key *poolKey, send func(ctx context.Context, ep *endpoint) error) error {
+	endpoints := c.endpoints.ordered()
+	c.probeUnhealthy(key)
+
+	var err error
+	for i, ep := range endpoints {
+		if openErr := c.breaker.allow(method, ep.url); openErr != nil {
+			if err == nil {
+				err = openErr
+			}
+			continue
+		}
+
+		ep.requests.Add(1)
+		attemptCtx, stop := attemptContext(ctx, len(endpoints)-i)
+		err = send(attemptCtx, ep)
+		err = stop(err)
+		if ctx.Err() != nil && !errors.Is(context.Cause(ctx), errCallTimeout) {
+			// The caller cancelled the call or its own deadline passed, which says nothing about the endpoint
+			c.breaker.abandon(method, ep.url)
+			break
+		}
+		if c.endpoints.observe(ep, err) {
+			c.logger.WarnContext(ctx, "ankr: endpoint marked unhealthy", "endpoint", ep.url, "error", err)
+		}
+		if state, changed := c.breaker.record(method, ep.url, err); changed {
+			c.logger.WarnContext(ctx, "ankr: circuit "+state.String(), "method", method, "endpoint", ep.url)
+		}
+		if !isEndpointFailure(err) || ctx.Err() != nil {
+			break
+		}
+		if i < len(endpoints)-1 {
+			c.logger.WarnContext(ctx, "ankr: endpoint failed, failing over", "endpoint", ep.url, "error", err)
+		}
+	}
+	return err
+}
+
+// attemptContext returns the context of an attempt with an even share of the time left before ctx's deadline,
+// left being the number of endpoints left to try
+//
+// stop must be called with the error of the attempt once its response is received. It cancels failed attempts,
+// and returns errAttemptTimeout if the attempt used up its share.
+// The context of a successful attempt is left running, so that streamed bodies can still be read.
+func attemptContext(ctx context.Context, left int) (attemptCtx context.Context, stop func(err error) error) {
+	deadline, ok := ctx.Deadline()
+	if !ok || left <= 1 {
+		return ctx, func(err error) error { return err }
+	}
+	attemptCtx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(time.Until(deadline)/time.Duration(left), func() {
+		cancel(errAttemptTimeout)
+	})
+	return attemptCtx, func(err error) error {
+		timer.Stop()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() == nil && context.Cause(attemptCtx) == errAttemptTimeout {
+			err = errAttemptTimeout
+		}
+		cancel(err)
+		return err
+	}
+}
+
+// probeUnhealthy probes, in the background, the unhealthy endpoints due for a probe
+// Probes are active calls of the client's lifecycle, none is started once the client is closed.
+func (c *HTTPClient) probeUnhealthy(key *poolKey) {
+	for _, ep := range c.endpoints.endpoints {
+		if !c.endpoints.shouldProbe(ep) {
+			continue
+		}
+		done, err := c.lifecycle.enter()
+		if err != nil {
+			ep.probing.Store(false)
+			return
+		}
+		go func() {
+			defer done()
+			c.probe(ep, key)
+		}()
+	}
+}
+
+// probe sends a GetBlockchainStats call to ep and marks it healthy if it succeeds
+//
+// Probes are billed like any call: they are charged to key and wait for its rate limiter as bulk calls.
+func (c *HTTPClient) probe(ep *endpoint, key *poolKey) {
+	defer ep.probing.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	settle, err := c.chargeCredits(ctx, key, MethodGetBlockchainStats)
+	if err != nil {
+		c.logger.DebugContext(ctx, "ankr: endpoint probe skipped", "endpoint", ep.url, "error", err)
+		return
+	}
+	c.waitLimiter(ctx, key.limiter, MethodGetBlockchainStats, PriorityBulk)
+
+	request := RPCReqBody{
+		ID:      c.nextID.Add(1),
+		JSONRPC: JSONRPC,
+		Method:  MethodGetBlockchainStats,
+		Params:  GetBlockchainStatsReq{Blockchain: c.endpoints.chain},
+	}
+	resp, err := c.send(ctx, ep.url+key.key, request, nil)
+	settle(statusOf(resp))
+	if err == nil {
+		err = checkRPCError(MethodGetBlockchainStats, resp)
+	}
+	if err != nil {
+		c.logger.DebugContext(ctx, "ankr: endpoint probe failed", "endpoint", ep.url, "error", err)
+		return
+	}
+
+	ep.mu.Lock()
+	ep.healthy = true
+	ep.consecutiveFailures = 0
+	ep.mu.Unlock()
+	c.logger.InfoContext(ctx, "ankr: endpoint recovered", "endpoint", ep.url)
+}
+
+// EndpointStats returns the health of every endpoint, in configuration order
+func (c *HTTPClient) EndpointStats() []EndpointStats {
+	stats := make([]EndpointStats, len(c.endpoints.endpoints))
+	for i, ep := range c.endpoints.endpoints {
+		stats[i] = ep.stats()
+	}
+	return stats
+}
@@ -0,0 +1,187 @@
+package ankr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestEndpointFailover tests failing over to the next endpoint and failing back once a probe succeeds
+func TestEndpointFailover(t *testing.T) {
+	var primaryDown atomic.Bool
+	var primaryCalls, primaryProbes, secondaryCalls atomic.Int32
+	primaryDown.Store(true)
+
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls.Add(1)
+		writeRPCResult(t, w, 1, GetCurrenciesResp{})
+	}))
+	t.Cleanup(secondary.Close)
+
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req RPCReqBody
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if req.Method == MethodGetBlockchainStats {
+			primaryProbes.Add(1)
+			if params, _ := req.Params.(map[string]any); params["blockchain"] != string(ChainPolygon) {
+				t.Errorf("Expected the probe to query the configured chain, got %v", req.Params)
+			}
+		} else {
+			primaryCalls.Add(1)
+		}
+		if primaryDown.Load() {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		writeRPCResult(t, w, 1, GetCurrenciesResp{})
+	}, HTTPClientConfig{
+		Endpoints:                []string{secondary.URL},
+		EndpointFailureThreshold: 1,
+		HealthCheckInterval:      20 * time.Millisecond,
+		HealthCheckChain:         ChainPolygon,
+		RetryPolicy:              NoRetry(),
+	})
+
+	ctx := context.Background()
+	call := func() {
+		t.Helper()
+		if _, err := client.GetCurrencies(ctx, GetCurrenciesReq{Blockchain: ChainEthereum}); err != nil {
+			t.Fatalf("GetCurrencies failed: %v", err)
+		}
+	}
+
+	call()
+	if primaryCalls.Load() != 1 || secondaryCalls.Load() != 1 {
+		t.Fatalf("Expected the call to fail over to the secondary, got %d and %d requests", primaryCalls.Load(), secondaryCalls.Load())
+	}
+	if stats := client.EndpointStats(); stats[0].Healthy || !stats[1].Healthy {
+		t.Fatalf("Expected the primary to be marked unhealthy, got %+v", stats)
+	}
+
+	call()
+	if primaryCalls.Load() != 1 || secondaryCalls.Load() != 2 {
+		t.Errorf("Expected calls to skip the unhealthy primary, got %d and %d requests", primaryCalls.Load(), secondaryCalls.Load())
+	}
+
+	primaryDown.Store(false)
+	deadline := time.Now().Add(2 * time.Second)
+	for !client.EndpointStats()[0].Healthy {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the primary to recover")
+		}
+		time.Sleep(25 * time.Millisecond)
+		call()
+	}
+	if primaryProbes.Load() == 0 {
+		t.Error("Expected the primary to be probed")
+	}
+	if credits := client.Credits().ByMethod[MethodGetBlockchainStats]; credits == 0 {
+		t.Error("Expected the successful probe to be charged")
+	}
+
+	before := primaryCalls.Load()
+	call()
+	if primaryCalls.Load() != before+1 {
+		t.Errorf("Expected calls to fail back to the primary, got %d requests", primaryCalls.Load()-before)
+	}
+}
+
+// TestEndpointTimeout tests that hanging endpoints time out within the call's timeout and are failed over,
+// while calls cancelled by their caller don't count against the endpoint
+func TestEndpointTimeout(t *testing.T) {
+	received := make(chan struct{}, 1)
+	var secondaryCalls atomic.Int32
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryCalls.Add(1)
+		writeRPCResult(t, w, 1, GetCurrenciesResp{})
+	}))
+	t.Cleanup(secondary.Close)
+
+	newClient := func(config HTTPClientConfig) *HTTPClient {
+		config.Endpoints = []string{secondary.URL}
+		config.EndpointFailureThreshold = 1
+		config.CircuitBreaker = &CircuitBreakerPolicy{FailureThreshold: 1}
+		config.RetryPolicy = NoRetry()
+		return newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case received <- struct{}{}:
+			default:
+			}
+			// The body is read so that the server notices when the client gives up
+			io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+		}, config)
+	}
+	req := GetCurrenciesReq{Blockchain: ChainEthereum}
+
+	timeouts := []struct {
+		name   string
+		config HTTPClientConfig
+		opts   []CallOption
+	}{
+		{"WithTimeout", HTTPClientConfig{}, []CallOption{WithTimeout(100 * time.Millisecond)}},
+		{"MethodTimeouts", HTTPClientConfig{MethodTimeouts: map[string]time.Duration{MethodGetCurrencies: 100 * time.Millisecond}}, nil},
+	}
+	for _, tt := range timeouts {
+		client, name := newClient(tt.config), tt.name
+		secondaryCalls.Store(0)
+		if _, err := client.GetCurrencies(context.Background(), req, tt.opts...); err != nil {
+			t.Fatalf("%s: expected the call to fail over, got %v", name, err)
+		}
+		stats := client.EndpointStats()
+		if stats[0].Healthy || stats[0].Failures != 1 || secondaryCalls.Load() != 1 {
+			t.Errorf("%s: expected the hanging primary to be marked unhealthy, got %+v", name, stats)
+		}
+		if state := client.CircuitState(MethodGetCurrencies, stats[0].URL); state != CircuitOpen {
+			t.Errorf("%s: expected the circuit to open, got %v", name, state)
+		}
+	}
+
+	<-received
+	client := newClient(HTTPClientConfig{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-received
+		cancel()
+	}()
+	secondaryCalls.Store(0)
+	if _, err := client.GetCurrencies(ctx, req, WithTimeout(time.Second)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the call to be cancelled, got %v", err)
+	}
+	stats := client.EndpointStats()
+	if !stats[0].Healthy || stats[0].Failures != 0 || secondaryCalls.Load() != 0 {
+		t.Errorf("Expected the primary to stay healthy without failing over, got %+v", stats)
+	}
+	if state := client.CircuitState(MethodGetCurrencies, stats[0].URL); state != CircuitClosed {
+		t.Errorf("Expected the circuit to stay closed, got %v", state)
+	}
+}
+
+// TestIsEndpointFailure tests which errors count against an endpoint's health
+func TestIsEndpointFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"5xx", &HTTPStatusError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"4xx", &HTTPStatusError{StatusCode: http.StatusTooManyRequests}, false},
+		{"rpc error", &RPCError{}, false},
+		{"canceled", context.Canceled, false},
+		{"timeout", context.DeadlineExceeded, true},
+	}
+	for _, tt := range tests {
+		if got := isEndpointFailure(tt.err); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
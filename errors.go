@@ -0,0 +1,165 @@
+package ankr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrRateLimited matches errors caused by Ankr rate limiting,
+	// such as HTTP 429 responses and rate limit RPC errors
+	ErrRateLimited = errors.New("ankr: rate limited")
+
+	// ErrQuotaExceeded matches errors caused by an exhausted plan quota or credit balance
+	ErrQuotaExceeded = errors.New("ankr: quota exceeded")
+
+	// ErrInvalidRequest matches errors of requests rejected by their Validate method
+	ErrInvalidRequest = errors.New("ankr: invalid request")
+)
+
+// maxErrorBodyLen is the maximum number of body bytes quoted in error messages
+const maxErrorBodyLen = 512
+
+// RPCError is returned when the API answers a call with a JSON-RPC error object
+//
+// Use errors.As to inspect the code, message and data of the error.
+type RPCError struct {
+	// Method is the JSON-RPC method of the failed call
+	Method string
+
+	RPCRespError
+}
+
+func (e *RPCError) Error() string {
+	msg := fmt.Sprintf("ankr: rpc error: code %d: %s", e.Code, e.Message)
+	if e.Method != "" {
+		msg = fmt.Sprintf("ankr: rpc error calling %s: code %d: %s", e.Method, e.Code, e.Message)
+	}
+	if e.Data != nil {
+		msg += fmt.Sprintf(" (data: %v)", e.Data)
+	}
+	return msg
+}
+
+// Is reports whether the RPC error is a rate limit or quota error
+func (e *RPCError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.Code == 429 || e.Code == -32005 || isRateLimitMessage(e.Message)
+	case ErrQuotaExceeded:
+		return isQuotaMessage(e.Message)
+	}
+	return false
+}
+
+// HTTPStatusError is returned when the API answers with a non-200 HTTP status
+type HTTPStatusError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// Header is the header of the response
+	Header http.Header
+
+	// Body is the raw body of the response
+	Body []byte
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("ankr: HTTP error %d: %s", e.StatusCode, truncateBody(e.Body))
+}
+
+// Is reports whether the HTTP error is a rate limit or quota error
+func (e *HTTPStatusError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests && !isQuotaMessage(string(e.Body))
+	case ErrQuotaExceeded:
+		return e.StatusCode == http.StatusPaymentRequired ||
+			(e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusForbidden) && isQuotaMessage(string(e.Body))
+	}
+	return false
+}
+
+// DecodeError is returned when a response body can't be decoded
+type DecodeError struct {
+	// Method is the JSON-RPC method of the call, empty for batches
+	Method string
+
+	// Body is the raw body that failed to decode
+	Body []byte
+
+	// Err is the underlying decoding error
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Method == "" {
+		return fmt.Sprintf("ankr: failed to decode response: %v", e.Err)
+	}
+	return fmt.Sprintf("ankr: failed to decode %s response: %v", e.Method, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned for a request rejected by its Validate method, before it is sent
+type ValidationError struct {
+	// Field is the name of the invalid field of the request
+	Field string
+
+	// Reason describes why the field is invalid
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("ankr: invalid request: %s %s", e.Field, e.Reason)
+}
+
+// Is makes ValidationError match ErrInvalidRequest
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidRequest
+}
+
+// ResponseTooLargeError is returned when a response body exceeds the client's MaxResponseSize
+type ResponseTooLargeError struct {
+	// Limit is the configured MaxResponseSize
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("ankr: response body exceeds the %d bytes limit", e.Limit)
+}
+
+// responseSizeError converts the error of a body read past its limit into a *ResponseTooLargeError
+func responseSizeError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return &ResponseTooLargeError{Limit: maxErr.Limit}
+	}
+	return err
+}
+
+// newRPCError converts a JSON-RPC error object into an *RPCError
+func newRPCError(method string, respErr *RPCRespError) *RPCError {
+	return &RPCError{Method: method, RPCRespError: *respErr}
+}
+
+func isRateLimitMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "rate limit") || strings.Contains(msg, "too many requests")
+}
+
+func isQuotaMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "quota") || strings.Contains(msg, "credits") || strings.Contains(msg, "insufficient balance")
+}
+
+func truncateBody(body []byte) string {
+	if len(body) <= maxErrorBodyLen {
+		return string(body)
+	}
+	return string(body[:maxErrorBodyLen]) + "...(truncated)"
+}
@@ -0,0 +1,79 @@
+package ankr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// TestHTTPStatusError tests that non-200 responses surface as *HTTPStatusError
+func TestHTTPStatusError(t *testing.T) {
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte("Too Many Requests"))
+	}, HTTPClientConfig{})
+
+	_, err := post[GetCurrenciesReq, *GetCurrenciesResp](context.Background(), client, MethodGetCurrencies, GetCurrenciesReq{Blockchain: ChainEthereum})
+
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Expected *HTTPStatusError, got %T: %v", err, err)
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", statusErr.StatusCode)
+	}
+	if statusErr.Header.Get("Retry-After") != "3" {
+		t.Errorf("Expected Retry-After header to be kept, got %q", statusErr.Header.Get("Retry-After"))
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Error("Expected error to match ErrRateLimited")
+	}
+	if errors.Is(err, ErrQuotaExceeded) {
+		t.Error("Expected error not to match ErrQuotaExceeded")
+	}
+}
+
+// TestRPCError tests that JSON-RPC error objects surface as *RPCError
+func TestRPCError(t *testing.T) {
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"monthly quota exceeded","data":"upgrade your plan"}}`))
+	}, HTTPClientConfig{})
+
+	_, err := post[GetCurrenciesReq, *GetCurrenciesResp](context.Background(), client, MethodGetCurrencies, GetCurrenciesReq{Blockchain: ChainEthereum})
+
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("Expected *RPCError, got %T: %v", err, err)
+	}
+	if rpcErr.Method != MethodGetCurrencies {
+		t.Errorf("Expected method %s, got %s", MethodGetCurrencies, rpcErr.Method)
+	}
+	if rpcErr.Code != -32000 {
+		t.Errorf("Expected code -32000, got %d", rpcErr.Code)
+	}
+	if rpcErr.Data != "upgrade your plan" {
+		t.Errorf("Expected data to be kept, got %v", rpcErr.Data)
+	}
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Error("Expected error to match ErrQuotaExceeded")
+	}
+}
+
+// TestDecodeError tests that malformed responses surface as *DecodeError
+func TestDecodeError(t *testing.T) {
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"currencies":[`))
+	}, HTTPClientConfig{})
+
+	_, err := post[GetCurrenciesReq, *GetCurrenciesResp](context.Background(), client, MethodGetCurrencies, GetCurrenciesReq{Blockchain: ChainEthereum})
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("Expected *DecodeError, got %T: %v", err, err)
+	}
+	if len(decodeErr.Body) == 0 {
+		t.Error("Expected the undecodable body to be kept")
+	}
+}
@@ -0,0 +1,174 @@
+package ankr
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Defaults of HedgePolicy
+const (
+	// DefaultHedgePercentile is the latency percentile after which a hedge is sent
+	DefaultHedgePercentile = 0.95
+
+	// DefaultHedgeInitialDelay is the hedge delay used until enough latencies are known
+	DefaultHedgeInitialDelay = 500 * time.Millisecond
+)
+
+// hedgeWindowSize is the number of recent latencies kept per method
+const hedgeWindowSize = 100
+
+// hedgeMinSamples is the number of latencies needed before the percentile is used
+const hedgeMinSamples = 20
+
+// HedgePolicy configures request hedging for latency-sensitive methods
+//
+// When a call of a hedged method hasn't answered within the Percentile of its recent latencies,
+// a duplicate is sent and whichever succeeds first is returned, the other one being cancelled.
+// Hedges go through middlewares and the rate limiter like any request,
+// and are reported with Metrics.ObserveHedge.
+type HedgePolicy struct {
+	// Methods are the JSON-RPC method names to hedge, e.g. MethodGetTokenPrice
+	Methods []string
+
+	// Percentile of recent latencies after which the hedge is sent, in (0, 1] (default: DefaultHedgePercentile)
+	Percentile float64
+
+	// InitialDelay is the hedge delay until enough latencies are known (default: DefaultHedgeInitialDelay)
+	InitialDelay time.Duration
+
+	// MinDelay is the lower bound of the hedge delay, to avoid doubling the load of fast methods
+	MinDelay time.Duration
+}
+
+// hedger tracks recent latencies of hedged methods and sends hedges
+type hedger struct {
+	percentile   float64
+	initialDelay time.Duration
+	minDelay     time.Duration
+	latencies    map[string]*latencyWindow
+}
+
+// newHedger creates the hedger of policy, nil if no method is hedged
+func newHedger(policy *HedgePolicy) *hedger {
+	if policy == nil || len(policy.Methods) == 0 {
+		return nil
+	}
+	h := &hedger{
+		percentile:   policy.Percentile,
+		initialDelay: policy.InitialDelay,
+		minDelay:     policy.MinDelay,
+		latencies:    make(map[string]*latencyWindow, len(policy.Methods)),
+	}
+	if h.percentile <= 0 || h.percentile > 1 {
+		h.percentile = DefaultHedgePercentile
+	}
+	if h.initialDelay <= 0 {
+		h.initialDelay = DefaultHedgeInitialDelay
+	}
+	for _, method := range policy.Methods {
+		h.latencies[method] = &latencyWindow{}
+	}
+	return h
+}
+
+// delay returns how long to wait for a call of method before hedging it
+func (h *hedger) delay(method string) time.Duration {
+	delay, ok := h.latencies[method].percentile(h.percentile)
+	if !ok {
+		delay = h.initialDelay
+	}
+	return max(delay, h.minDelay)
+}
+
+// latencyWindow keeps the most recent latencies of a method
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (w *latencyWindow) observe(latency time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.samples) < hedgeWindowSize {
+		w.samples = append(w.samples, latency)
+		return
+	}
+	w.samples[w.next] = latency
+	w.next = (w.next + 1) % hedgeWindowSize
+}
+
+// percentile returns the p-th percentile of the window, ok is false until there are enough samples
+func (w *latencyWindow) percentile(p float64) (time.Duration, bool) {
+	w.mu.Lock()
+	samples := slices.Clone(w.samples)
+	w.mu.Unlock()
+	if len(samples) < hedgeMinSamples {
+		return 0, false
+	}
+	slices.Sort(samples)
+	i := int(p*float64(len(samples))+0.5) - 1
+	return samples[min(max(i, 0), len(samples)-1)], true
+}
+
+// hedge calls call, and calls it again if it hasn't answered within the hedge delay of method
+//
+// The first success is returned and the other call is cancelled.
+// If the first call fails before the hedge is sent, its error is returned right away;
+// once both are sent, an error is only returned if both fail.
+func (c *HTTPClient) hedge(ctx context.Context, method string, call func(ctx context.Context) (*RPCResponse, error)) (*RPCResponse, error) {
+	if c.hedger == nil {
+		return call(ctx)
+	}
+	window, ok := c.hedger.latencies[method]
+	if !ok {
+		return call(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		resp  *RPCResponse
+		err   error
+		hedge bool
+	}
+	results := make(chan result, 2)
+	launch := func(hedge bool) {
+		go func() {
+			start := time.Now()
+			resp, err := call(ctx)
+			if err == nil {
+				window.observe(time.Since(start))
+			}
+			results <- result{resp, err, hedge}
+		}()
+	}
+
+	launch(false)
+	timer := time.NewTimer(c.hedger.delay(method))
+	defer timer.Stop()
+
+	hedged, pending := false, 1
+	for {
+		select {
+		case <-timer.C:
+			hedged = true
+			pending++
+			c.logger.DebugContext(ctx, "ankr: hedging request", "method", method)
+			launch(true)
+		case r := <-results:
+			pending--
+			if r.err != nil && hedged && pending > 0 {
+				// The other call may still succeed
+				continue
+			}
+			if hedged {
+				c.metrics.ObserveHedge(method, r.err == nil && r.hedge)
+			}
+			return r.resp, r.err
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ankr
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestHedge tests that a slow call is hedged and the first answer wins
+func TestHedge(t *testing.T) {
+	var calls atomic.Int32
+	metrics := NewPrometheusMetrics()
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// The first attempt hangs until it is cancelled
+			io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+			return
+		}
+		writeRPCResult(t, w, 1, GetTokenPriceResp{UsdPrice: "2"})
+	}, HTTPClientConfig{
+		Metrics: metrics,
+		HedgePolicy: &HedgePolicy{
+			Methods:      []string{MethodGetTokenPrice},
+			InitialDelay: 20 * time.Millisecond,
+		},
+	})
+
+	start := time.Now()
+	resp, err := client.GetTokenPrice(context.Background(), GetTokenPriceReq{Blockchain: ChainEthereum})
+	if err != nil {
+		t.Fatalf("GetTokenPrice failed: %v", err)
+	}
+	if resp.UsdPrice != "2" {
+		t.Errorf("Expected the hedge's answer, got %q", resp.UsdPrice)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the hedge to answer quickly, took %v", elapsed)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 requests, got %d", calls.Load())
+	}
+
+	var out strings.Builder
+	metrics.WriteTo(&out)
+	if !strings.Contains(out.String(), `ankr_hedges_total{method="ankr_getTokenPrice",outcome="won"} 1`) {
+		t.Errorf("Expected the won hedge in metrics, got:\n%s", out.String())
+	}
+
+	// Methods not listed are never hedged
+	calls.Store(1)
+	if _, err := client.GetCurrencies(context.Background(), GetCurrenciesReq{Blockchain: ChainEthereum}); err != nil {
+		t.Fatalf("GetCurrencies failed: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 1 request for an unhedged method, got %d", calls.Load()-1)
+	}
+}
+
+// TestLatencyWindowPercentile tests the hedge delay computed from recent latencies
+func TestLatencyWindowPercentile(t *testing.T) {
+	var w latencyWindow
+	if _, ok := w.percentile(0.95); ok {
+		t.Error("Expected no percentile without samples")
+	}
+	for i := range 2 * hedgeWindowSize {
+		w.observe(time.Duration(i%hedgeWindowSize+1) * time.Millisecond)
+	}
+	if p, _ := w.percentile(0.95); p != 95*time.Millisecond {
+		t.Errorf("Expected p95 of 95ms, got %v", p)
+	}
+	if p, _ := w.percentile(0.5); p != 50*time.Millisecond {
+		t.Errorf("Expected p50 of 50ms, got %v", p)
+	}
+}
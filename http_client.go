@@ -3,25 +3,172 @@ package ankr
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// DefaultBaseURL is the Ankr multichain endpoint the API key is appended to
+const DefaultBaseURL = "https://rpc.ankr.com/multichain/"
+
+// DefaultTimeout is the overall timeout of the default http.Client
+const DefaultTimeout = 90 * time.Second
+
 // HTTPClient represents the HTTP client for Ankr Advanced API
 type HTTPClient struct {
-	uri         string
-	httpClient  *http.Client
-	rateLimiter *SimpleLimiter
+	httpClient      *http.Client
+	methodTimeouts  map[string]time.Duration
+	retryPolicy     RetryPolicy
+	handler         Handler
+	endpoints       *endpointPool
+	keys            *keyPool
+	logger          *slog.Logger
+	metrics         Metrics
+	tracer          Tracer
+	cache           Cache
+	cacheTTLs       map[string]time.Duration
+	dedup           bool
+	maxResponseSize int64
+	hedger          *hedger
+	breaker         *circuitBreaker
+	credits         *creditMeter
+	debugger        *debugger
+	flights         flightGroup
+	lifecycle       lifecycle
+	nextID          atomic.Int64
 }
 
 type HTTPClientConfig struct {
+	// APIKey is the key appended to BaseURL
+	// When APIKeys is set too, it is the first key of the pool
 	APIKey string
+
+	// APIKeys is a pool of keys, each with its own rate limiter and usage counters
+	// A key that gets rate limited or runs out of quota is parked and the call moves on to the next key
+	APIKeys []APIKey
+
+	// KeySelection decides which available key sends each request (default: KeyRoundRobin)
+	KeySelection KeySelection
+
+	// KeyParkDuration is how long a rate limited key is parked when the response has no Retry-After header
+	// (default: DefaultKeyParkDuration)
+	KeyParkDuration time.Duration
+
+	// KeyQuotaParkDuration is how long a key with an exhausted quota is parked (default: DefaultKeyQuotaParkDuration)
+	KeyQuotaParkDuration time.Duration
+
+	// BulkShare is the minimum share of a key's rate limit granted to waiting PriorityBulk calls
+	// (default: DefaultBulkShare), see SimpleLimiter.SetBulkShare
+	BulkShare float64
 	// OnLimitExceeded RateLimitBehavior `default:"block"`
+
+	// BaseURL is the endpoint the API key is appended to (default: DefaultBaseURL)
+	// Use it to point the client at a gateway, a proxy or a local stand-in server
+	// When Endpoints is set too, it is the first endpoint
+	BaseURL string
+
+	// Endpoints are base URLs tried in order, e.g. the Ankr endpoint followed by a regional mirror
+	// Calls fail over to the next endpoint on 5xx responses, timeouts and network errors
+	Endpoints []string
+
+	// EndpointFailureThreshold is the number of consecutive failures that mark an endpoint unhealthy
+	// (default: DefaultEndpointFailureThreshold)
+	EndpointFailureThreshold int
+
+	// HealthCheckInterval is the minimum time between two probes of an unhealthy endpoint
+	// (default: DefaultHealthCheckInterval)
+	HealthCheckInterval time.Duration
+
+	// HealthCheckChain is the chain of the GetBlockchainStats calls probing unhealthy endpoints
+	// (default: DefaultHealthCheckChain)
+	HealthCheckChain Chain
+
+	// HTTPClient is used as is when set
+	// Transport, Proxy, TLSConfig and Timeout are ignored in that case
+	HTTPClient *http.Client
+
+	// Transport is the round tripper of the default http.Client
+	// Proxy and TLSConfig are ignored when it is set
+	Transport http.RoundTripper
+
+	// Proxy is the proxy function of the default transport, e.g. http.ProxyFromEnvironment
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// TLSConfig is the TLS configuration of the default transport
+	TLSConfig *tls.Config
+
+	// Timeout is the overall timeout of the default http.Client (default: DefaultTimeout)
+	Timeout time.Duration
+
+	// MethodTimeouts is the default timeout of a single call keyed by JSON-RPC method name
+	// It only applies when the caller's context has no earlier deadline
+	MethodTimeouts map[string]time.Duration
+
+	// RetryPolicy decides whether and when failed calls are retried (default: DefaultRetryPolicy())
+	// Use NoRetry() to disable retries
+	RetryPolicy RetryPolicy
+
+	// Middlewares wrap every JSON-RPC call, the first one being the outermost
+	Middlewares []Middleware
+
+	// Logger receives the client's logs (default: slog.Default())
+	// Retries are logged at Warn level, rate limiter waits and pagination at Debug level
+	// Use slog.New(slog.DiscardHandler) to silence the client
+	Logger *slog.Logger
+
+	// Metrics receives per-request instrumentation, e.g. a *PrometheusMetrics
+	Metrics Metrics
+
+	// Tracer opens a span per post attempt and per Pages.Next call
+	Tracer Tracer
+
+	// Cache stores responses of the methods listed in CacheTTLs
+	// A MemoryCache of DefaultCacheSize entries is created when only CacheTTLs is set
+	Cache Cache
+
+	// CacheTTLs is how long responses are cached, keyed by JSON-RPC method name
+	// Methods not listed are not cached, NoExpiration caches forever
+	// DefaultCacheTTLs() is used when only Cache is set
+	CacheTTLs map[string]time.Duration
+
+	// DeduplicateRequests collapses identical concurrent calls, keyed by method, params and tenant,
+	// into a single request whose response is shared by all callers
+	DeduplicateRequests bool
+
+	// MaxResponseSize is the maximum size in bytes of a response body, 0 means unlimited
+	// Larger responses fail with a *ResponseTooLargeError
+	MaxResponseSize int64
+
+	// HedgePolicy sends a duplicate of slow calls of latency-sensitive methods
+	HedgePolicy *HedgePolicy
+
+	// CircuitBreaker opens a circuit per method and endpoint after repeated failures,
+	// so that calls fail fast with a *CircuitOpenError instead of waiting for timeouts
+	CircuitBreaker *CircuitBreakerPolicy
+
+	// CreditPrices is the estimated credits per call keyed by JSON-RPC method name
+	// Methods not listed cost DefaultCreditPrice
+	CreditPrices map[string]int64
+
+	// Budgets cap the credits spent per time window, see Budget
+	Budgets []Budget
+
+	// Debug writes every HTTP request as a runnable curl command, with the API key replaced by $ANKR_API_KEY,
+	// followed by its params after defaults, its status, timing and truncated response body
+	Debug bool
+
+	// DebugOutput receives the dumps of Debug (default: os.Stderr)
+	DebugOutput io.Writer
 }
 
 // NewHTTPClient creates a new HTTP client with the given configuration
@@ -30,137 +177,465 @@ func NewHTTPClient(config *HTTPClientConfig) *HTTPClient {
 		config = &HTTPClientConfig{}
 	}
 
-	// Create rate limiter
-	rateLimiter := NewSimpleLimiter(time.Minute, 1000)
-
 	// Create HTTP client
-	httpClient := &http.Client{
-		Timeout: 90 * time.Second,
-		Transport: &http.Transport{
-			IdleConnTimeout: 90 * time.Second,
-		},
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		transport := config.Transport
+		if transport == nil {
+			transport = &http.Transport{
+				Proxy:           config.Proxy,
+				TLSClientConfig: config.TLSConfig,
+				IdleConnTimeout: 90 * time.Second,
+			}
+		}
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		httpClient = &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+		}
+	}
+
+	var retryPolicy RetryPolicy = DefaultRetryPolicy()
+	if config.RetryPolicy != nil {
+		retryPolicy = config.RetryPolicy
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
 	}
 
-	return &HTTPClient{
-		uri:         "https://rpc.ankr.com/multichain/" + config.APIKey,
-		httpClient:  httpClient,
-		rateLimiter: rateLimiter,
+	var metrics Metrics = noopMetrics{}
+	if config.Metrics != nil {
+		metrics = config.Metrics
 	}
+
+	var tracer Tracer = noopTracer{}
+	if config.Tracer != nil {
+		tracer = config.Tracer
+	}
+
+	cache, cacheTTLs := config.Cache, maps.Clone(config.CacheTTLs)
+	switch {
+	case cache == nil && len(cacheTTLs) > 0:
+		cache = NewMemoryCache(DefaultCacheSize)
+	case cache != nil && cacheTTLs == nil:
+		cacheTTLs = DefaultCacheTTLs()
+	}
+
+	client := &HTTPClient{
+		endpoints:       newEndpointPool(config),
+		keys:            newKeyPool(config),
+		logger:          logger,
+		metrics:         metrics,
+		tracer:          tracer,
+		cache:           cache,
+		cacheTTLs:       cacheTTLs,
+		dedup:           config.DeduplicateRequests,
+		maxResponseSize: config.MaxResponseSize,
+		hedger:          newHedger(config.HedgePolicy),
+		breaker:         newCircuitBreaker(config.CircuitBreaker),
+		credits:         newCreditMeter(config),
+		debugger:        newDebugger(config),
+		httpClient:      httpClient,
+		methodTimeouts:  maps.Clone(config.MethodTimeouts),
+		retryPolicy:     retryPolicy,
+	}
+	client.handler = chainMiddlewares(client.roundTrip, config.Middlewares)
+
+	return client
+}
+
+// withMethodTimeout applies the configured default timeout of method to ctx
+func (c *HTTPClient) withMethodTimeout(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	timeout, ok := c.methodTimeouts[method]
+	if !ok || timeout <= 0 {
+		return ctx, func() {}
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, timeout, errCallTimeout)
 }
 
 // post makes a JSON-RPC post request and returns the result with generic type
-func post[Req any, Resp any](ctx context.Context, client *HTTPClient, method string, params Req) (result Resp, isRPCError bool, err error) {
-	// Rate limiting
-	client.rateLimiter.Wait(ctx)
+//
+// Params failing validation are returned as *ValidationError, RPC errors as *RPCError,
+// non-200 responses as *HTTPStatusError and undecodable responses as *DecodeError.
+func post[Req any, Resp any](ctx context.Context, client *HTTPClient, method string, params Req, opts ...CallOption) (result Resp, err error) {
+	o := newCallOptions(opts)
+	ctx, cancel := client.withMethodTimeout(ctx, method)
+	defer cancel()
+
+	newParams, err := applyDefaults(params)
+	if err != nil {
+		return result, fmt.Errorf("failed to apply defaults: %w", err)
+	}
+	if err := validate(newParams); err != nil {
+		return result, err
+	}
+
+	dedup := client.dedup && o.header == nil
+
+	var key string
+	if client.cache != nil || dedup {
+		if key, err = requestKey(method, newParams); err != nil {
+			return result, fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	cacheTTL, cached := client.cacheTTL(method)
+	cached = cached && o.cache != cacheBypass
+	if cached && o.cache != cacheRefresh && !cacheBypassed(ctx) {
+		if body, ok := client.cache.Get(key); ok {
+			client.logger.DebugContext(ctx, "ankr: cache hit", "method", method)
+			return decodeResult[Resp](method, body)
+		}
+	}
+
+	call := func(ctx context.Context) (*RPCResponse, error) {
+		return client.hedge(ctx, method, func(ctx context.Context) (*RPCResponse, error) {
+			return client.handler(ctx, &RPCRequest{
+				Method:   method,
+				Params:   newParams,
+				Header:   o.newHeader(),
+				Priority: o.priority,
+			})
+		})
+	}
+
+	var resp *RPCResponse
+	if dedup {
+		// Flights aren't shared across tenants, each one is charged for its own calls
+		resp, err = client.flights.do(ctx, tenantOf(ctx)+"\x00"+key, call)
+	} else {
+		resp, err = call(ctx)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	result, err = decodeResult[Resp](method, resp.Body)
+	if err != nil {
+		return result, err
+	}
+
+	if cached {
+		if r, ok := any(result).(cacheableResult); !ok || r.cacheable() {
+			client.cache.Set(key, resp.Body, cacheTTL)
+		}
+	}
+
+	return result, nil
+}
+
+// decodeResult parses a JSON-RPC response body into its typed result
+func decodeResult[Resp any](method string, body []byte) (result Resp, err error) {
+	var apiResponse RPCRespBody[Resp]
+	if err := json.Unmarshal(body, &apiResponse); err != nil {
+		return result, &DecodeError{Method: method, Body: body, Err: err}
+	}
+
+	if apiResponse.Error != nil {
+		return result, newRPCError(method, apiResponse.Error)
+	}
 
-	newParams, err := ApplyDefaults(params)
+	return apiResponse.Result, nil
+}
+
+// roundTrip is the innermost Handler, it rate limits and sends a single JSON-RPC call
+//
+// A key that gets rate limited or runs out of quota is parked,
+// and the call is sent again with the next available key of the pool.
+// So is a call exceeding the budget of its key.
+func (c *HTTPClient) roundTrip(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+	key, _ := c.keys.pick(nil)
+	tried := make(map[*poolKey]bool, 1)
+	for {
+		tried[key] = true
+		resp, err := c.roundTripWithKey(ctx, key, req)
+		var budgetErr *BudgetExceededError
+		switch {
+		case c.keys.observe(key, err):
+			c.logger.WarnContext(ctx, "ankr: parked API key", "key", maskKey(key.key), "method", req.Method, "error", err)
+		case errors.As(err, &budgetErr) && budgetErr.Budget.Key != "":
+			c.logger.DebugContext(ctx, "ankr: key budget exceeded", "key", maskKey(key.key), "method", req.Method)
+		default:
+			return resp, err
+		}
+
+		next, ok := c.keys.pick(tried)
+		if !ok || next.parked() || ctx.Err() != nil {
+			return resp, err
+		}
+		key = next
+	}
+}
+
+// roundTripWithKey rate limits and sends a single JSON-RPC call with key
+func (c *HTTPClient) roundTripWithKey(ctx context.Context, key *poolKey, req *RPCRequest) (*RPCResponse, error) {
+	// Open circuits and budgets are checked first, so that rejected calls fail fast
+	// without being charged nor taking a token of the rate limiter
+	if err := c.breaker.rejectsAll(req.Method, c.endpoints.endpoints); err != nil {
+		return nil, err
+	}
+	settle, err := c.chargeCredits(ctx, key, req.Method)
 	if err != nil {
-		return result, false, fmt.Errorf("failed to apply defaults: %w", err)
+		return nil, err
 	}
 
+	// Rate limiting
+	c.waitLimiter(ctx, key.limiter, req.Method, req.Priority)
+
 	// Create JSON-RPC request
 	request := RPCReqBody{
-		ID:      1,
+		ID:      c.nextID.Add(1),
 		JSONRPC: JSONRPC,
-		Method:  method,
-		Params:  newParams,
+		Method:  req.Method,
+		Params:  req.Params,
 	}
 
-	// Marshal request body
-	requestBody, err := json.Marshal(request)
-	if err != nil {
-		return result, false, fmt.Errorf("failed to marshal request: %w", err)
+	resp, err := c.sendWithKey(ctx, req.Method, key, request, req.Header)
+	settle(statusOf(resp))
+	if resp != nil {
+		postSpan(ctx).SetAttributes(
+			Attribute{AttrStatusCode, resp.StatusCode},
+			Attribute{AttrResponseSize, len(resp.Body)},
+		)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", client.uri, bytes.NewReader(requestBody))
-	if err != nil {
-		return result, false, fmt.Errorf("failed to create request: %w", err)
+	return resp, err
+}
+
+// waitLimiter blocks until limiter lets a call of method with priority through
+func (c *HTTPClient) waitLimiter(ctx context.Context, limiter *SimpleLimiter, method string, priority Priority) {
+	start := time.Now()
+	limiter.WaitPriority(ctx, priority)
+	waited := time.Since(start)
+	c.metrics.ObserveLimiterWait(method, waited)
+	if waited >= time.Millisecond {
+		c.logger.DebugContext(ctx, "ankr: waited for rate limiter", "method", method, "priority", priority, "waited", waited)
 	}
+}
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
+// observeRequest reports a request sent at start to the client's metrics
+func (c *HTTPClient) observeRequest(method string, start time.Time, resp *RPCResponse, err error) {
+	stats := RequestStats{
+		Method:   method,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if resp != nil {
+		stats.StatusCode = resp.StatusCode
+		stats.BytesReceived = len(resp.Body)
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		stats.RPCErrorCode = rpcErr.Code
+	}
+	c.metrics.ObserveRequest(stats)
+}
 
-	// Make the request
-	resp, err := client.httpClient.Do(req)
+// checkRPCError surfaces the JSON-RPC error object of a response as an error,
+// so that middlewares see it
+func checkRPCError(method string, resp *RPCResponse) error {
+	var envelope struct {
+		Error *RPCRespError `json:"error"`
+	}
+	if err := json.Unmarshal(resp.Body, &envelope); err != nil {
+		return &DecodeError{Method: method, Body: resp.Body, Err: err}
+	}
+	if envelope.Error != nil {
+		return newRPCError(method, envelope.Error)
+	}
+	return nil
+}
+
+// sendWithKey sends payload with key and keeps track of the key's usage
+func (c *HTTPClient) sendWithKey(ctx context.Context, method string, key *poolKey, payload any, header http.Header) (*RPCResponse, error) {
+	key.requests.Add(1)
+	key.inFlight.Add(1)
+	defer key.inFlight.Add(-1)
+	return c.sendWithFailover(ctx, method, key, payload, header)
+}
+
+// send posts a JSON-RPC payload, either a single request or a batch, to uri and returns the raw response
+// The response is also returned along with an *HTTPStatusError
+func (c *HTTPClient) send(ctx context.Context, uri string, payload any, header http.Header) (*RPCResponse, error) {
+	resp, err := c.do(ctx, uri, payload, header)
 	if err != nil {
-		return result, false, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return result, false, fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", responseSizeError(err))
+	}
+
+	rpcResp := &RPCResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
 	}
 
 	// Check HTTP status
 	if resp.StatusCode != http.StatusOK {
-		return result, false, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
+		return rpcResp, &HTTPStatusError{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
 	}
 
-	// Parse JSON response
-	var apiResponse RPCRespBody[Resp]
-	if err := json.Unmarshal(body, &apiResponse); err != nil {
-		return result, false, fmt.Errorf("failed to parse response: %w", err)
+	return rpcResp, nil
+}
+
+// do posts a JSON-RPC payload to uri and returns the response with its body still open
+// The body is limited to the client's MaxResponseSize
+func (c *HTTPClient) do(ctx context.Context, uri string, payload any, header http.Header) (*http.Response, error) {
+	// Marshal request body
+	requestBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	if apiResponse.Error != nil {
-		return result, true, fmt.Errorf("ankr: rpc error: %+v", apiResponse.Error)
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, "POST", uri, bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", c.redactError(err))
+	}
+
+	// Set headers
+	for key, values := range header {
+		req.Header[key] = values
 	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Make the request
+	dump := c.debugRequest(req, payload, requestBody)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("request failed: %w", c.redactError(err))
+		dump.response(nil, err)
+		return nil, err
+	}
+	dump.response(resp, nil)
+
+	if c.maxResponseSize > 0 {
+		if resp.ContentLength > c.maxResponseSize {
+			resp.Body.Close()
+			return nil, &ResponseTooLargeError{Limit: c.maxResponseSize}
+		}
+		resp.Body = http.MaxBytesReader(nil, resp.Body, c.maxResponseSize)
+	}
+
+	return resp, nil
+}
 
-	return apiResponse.Result, false, nil
+// postWithRetries makes a call with retries, unless the client is closed
+func postWithRetries[Req any, Resp any](ctx context.Context, client *HTTPClient, method string, params Req, opts ...CallOption) (result Resp, err error) {
+	done, err := client.lifecycle.enter()
+	if err != nil {
+		return result, err
+	}
+	defer done()
+	return retryPost[Req, Resp](ctx, client, method, params, opts...)
 }
 
-func postWithRetries[Req any, Resp any](ctx context.Context, client *HTTPClient, method string, params Req, retries int) (result Resp, err error) {
-	for range retries {
-		result, _, err = post[Req, Resp](ctx, client, method, params)
+// retryPost calls post until it succeeds or the retry policy of the call gives up
+func retryPost[Req any, Resp any](ctx context.Context, client *HTTPClient, method string, params Req, opts ...CallOption) (result Resp, err error) {
+	o := newCallOptions(opts)
+	retryPolicy := o.retryPolicyOr(client.retryPolicy)
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		attemptCtx, span := client.startPostSpan(ctx, method, params, attempt)
+		result, err = post[Req, Resp](attemptCtx, client, method, params, opts...)
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
 		if err == nil {
 			return
 		}
-		slog.Error("ankr: failed to post, retrying...", "error", err)
-		time.Sleep(time.Second)
-		continue
-
+		if ctx.Err() != nil {
+			return result, fmt.Errorf("ankr: %s failed after %d attempt(s): %w", method, attempt, err)
+		}
+		delay, retry := retryPolicy.Backoff(attempt, err)
+		if !retry {
+			return result, fmt.Errorf("ankr: %s failed after %d attempt(s): %w", method, attempt, err)
+		}
+		client.metrics.ObserveRetry(method, attempt)
+		client.logger.WarnContext(ctx, "ankr: failed to post, retrying...", "method", method, "attempt", attempt, "delay", delay, "error", err)
+		if ctxErr := sleepCtx(ctx, delay); ctxErr != nil {
+			return result, fmt.Errorf("ankr: %s aborted after %d attempt(s): %w, last error: %w", method, attempt, ctxErr, err)
+		}
 	}
-	err = fmt.Errorf("ankr: failed to post after %d retries, last error: %w", retries, err)
-	return
 }
 
-type nextPageFunc[Page any] func(ctx context.Context) (Page, bool, error)
+type nextPageFunc[Page any] func(ctx context.Context, opts []CallOption) (Page, bool, error)
 
-type reqData interface {
-	setPageToken(string)
-}
+// makeNextPageFunc fetches the pages of method, every page with opts followed by the options of Pages.Next
+//
+// The scan is registered with the client's lifecycle from its first page until its last one, its first error
+// or stop, so that Close waits for it.
+func makeNextPageFunc[Req PageRequest, Resp PageResponse](client *HTTPClient, method string, req Req, opts []CallOption) (next nextPageFunc[Resp], stop func()) {
+	page := 0
+	var done func()
+	stop = func() {
+		if done != nil {
+			done()
+			done = nil
+		}
+	}
+	next = func(ctx context.Context, pageOpts []CallOption) (resp Resp, hasNext bool, err error) {
+		if done == nil {
+			if done, err = client.lifecycle.enter(); err != nil {
+				return resp, false, err
+			}
+		}
 
-type respData interface {
-	getNextPageToken() string
-}
+		ctx, span := client.tracer.Start(ctx, SpanPagesNext,
+			Attribute{AttrRPCMethod, method},
+			Attribute{AttrChain, chainOf(req)},
+			Attribute{AttrPage, page + 1},
+		)
+		defer span.End()
 
-func makeNextPageFunc[Req reqData, Resp respData](client *HTTPClient, method string, req Req) nextPageFunc[Resp] {
-	return func(ctx context.Context) (resp Resp, hasNext bool, err error) {
-		resp, err = postWithRetries[Req, Resp](ctx, client, method, req, 3)
+		resp, err = retryPost[Req, Resp](ctx, client, method, req, append(slices.Clip(opts), pageOpts...)...)
 		if err != nil {
+			span.RecordError(err)
+			stop()
 			return resp, false, err
 		}
-		hasNext = resp.getNextPageToken() != ""
+		page++
+		hasNext = resp.GetNextPageToken() != ""
 		if hasNext {
-			req.setPageToken(resp.getNextPageToken())
+			req.SetPageToken(resp.GetNextPageToken())
+		} else {
+			stop()
 		}
+		span.SetAttributes(Attribute{AttrHasNext, hasNext})
+		client.logger.DebugContext(ctx, "ankr: fetched page", "method", method, "page", page, "hasNext", hasNext)
 		return resp, hasNext, nil
 	}
+	return next, stop
 }
 
 type Pages[Page any] struct {
 	hasNext bool
 	mu      sync.RWMutex
 	next    nextPageFunc[Page]
+	stop    func()
 }
 
-func newPages[Page any](next nextPageFunc[Page]) *Pages[Page] {
+func newPages[Page any](next nextPageFunc[Page], stop func()) *Pages[Page] {
 	return &Pages[Page]{
 		hasNext: true,
 		next:    next,
+		stop:    stop,
 	}
 }
 
@@ -170,15 +645,16 @@ func (p *Pages[Page]) HasNext() bool {
 	return p.hasNext
 }
 
-func (p *Pages[Page]) Next(ctx context.Context) (newPage Page, err error) {
+// Next fetches the next page, opts applying to this page only
+func (p *Pages[Page]) Next(ctx context.Context, opts ...CallOption) (newPage Page, err error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	if !p.hasNext {
-		// should never happen
-		err = fmt.Errorf("ankr: Pages has no next func")
+		// the scan is over or stopped
+		err = fmt.Errorf("ankr: Pages has no next page")
 		return
 	}
-	newPage, ok, err := p.next(ctx)
+	newPage, ok, err := p.next(ctx, opts)
 	if err != nil {
 		return
 	}
@@ -186,6 +662,17 @@ func (p *Pages[Page]) Next(ctx context.Context) (newPage Page, err error) {
 	return newPage, nil
 }
 
+// Stop abandons the scan before its last page, so that HTTPClient.Close doesn't wait for it
+//
+// It waits for the page being fetched, if any. HasNext reports false afterwards.
+// Stopping a scan that is over is a no-op.
+func (p *Pages[Page]) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hasNext = false
+	p.stop()
+}
+
 // ============================================================================
 // NFT API Methods
 // ============================================================================
@@ -198,11 +685,12 @@ func (p *Pages[Page]) Next(ctx context.Context) (newPage Page, err error) {
 //
 // Args:
 //   - req: Request parameters including wallet address, blockchain, page size, etc.
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *Pages[GetNFTsByOwnerResp]: Paginated response iterator
-func (c *HTTPClient) GetNFTsByOwner(req GetNFTsByOwnerReq) *Pages[*GetNFTsByOwnerResp] {
-	return newPages(makeNextPageFunc[*GetNFTsByOwnerReq, *GetNFTsByOwnerResp](c, "ankr_getNFTsByOwner", &req))
+func (c *HTTPClient) GetNFTsByOwner(req GetNFTsByOwnerReq, opts ...CallOption) *Pages[*GetNFTsByOwnerResp] {
+	return newPages(makeNextPageFunc[*GetNFTsByOwnerReq, *GetNFTsByOwnerResp](c, MethodGetNFTsByOwner, &req, opts))
 }
 
 // GetNFTMetadata retrieves metadata of a particular NFT
@@ -213,12 +701,13 @@ func (c *HTTPClient) GetNFTsByOwner(req GetNFTsByOwnerReq) *Pages[*GetNFTsByOwne
 // Args:
 //   - ctx: Context for cancellation
 //   - req: Request parameters including blockchain, contract address, and token ID
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *GetNFTMetadataResp: Response containing NFT metadata
 //   - error: Error if the request fails
-func (c *HTTPClient) GetNFTMetadata(ctx context.Context, req GetNFTMetadataReq) (*GetNFTMetadataResp, error) {
-	return postWithRetries[GetNFTMetadataReq, *GetNFTMetadataResp](ctx, c, "ankr_getNFTMetadata", req, 3)
+func (c *HTTPClient) GetNFTMetadata(ctx context.Context, req GetNFTMetadataReq, opts ...CallOption) (*GetNFTMetadataResp, error) {
+	return postWithRetries[GetNFTMetadataReq, *GetNFTMetadataResp](ctx, c, MethodGetNFTMetadata, req, opts...)
 }
 
 // GetNFTHolders retrieves holders of a particular NFT with automatic pagination
@@ -228,11 +717,12 @@ func (c *HTTPClient) GetNFTMetadata(ctx context.Context, req GetNFTMetadataReq)
 //
 // Args:
 //   - req: Request parameters including blockchain, contract address, and pagination
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *Pages[GetNFTHoldersResp]: Paginated response iterator
-func (c *HTTPClient) GetNFTHolders(req GetNFTHoldersReq) *Pages[*GetNFTHoldersResp] {
-	return newPages(makeNextPageFunc[*GetNFTHoldersReq, *GetNFTHoldersResp](c, "ankr_getNFTHolders", &req))
+func (c *HTTPClient) GetNFTHolders(req GetNFTHoldersReq, opts ...CallOption) *Pages[*GetNFTHoldersResp] {
+	return newPages(makeNextPageFunc[*GetNFTHoldersReq, *GetNFTHoldersResp](c, MethodGetNFTHolders, &req, opts))
 }
 
 // GetNFTTransfers retrieves NFT transfers info with automatic pagination
@@ -243,11 +733,12 @@ func (c *HTTPClient) GetNFTHolders(req GetNFTHoldersReq) *Pages[*GetNFTHoldersRe
 //
 // Args:
 //   - req: Request parameters including addresses, blockchain(s), and range filters
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *Pages[GetNFTTransfersResp]: Paginated response iterator
-func (c *HTTPClient) GetNFTTransfers(req GetNFTTransfersReq) *Pages[*GetNFTTransfersResp] {
-	return newPages(makeNextPageFunc[*GetNFTTransfersReq, *GetNFTTransfersResp](c, "ankr_getNftTransfers", &req))
+func (c *HTTPClient) GetNFTTransfers(req GetNFTTransfersReq, opts ...CallOption) *Pages[*GetNFTTransfersResp] {
+	return newPages(makeNextPageFunc[*GetNFTTransfersReq, *GetNFTTransfersResp](c, MethodGetNFTTransfers, &req, opts))
 }
 
 // ============================================================================
@@ -262,12 +753,13 @@ func (c *HTTPClient) GetNFTTransfers(req GetNFTTransfersReq) *Pages[*GetNFTTrans
 // Args:
 //   - ctx: Context for cancellation
 //   - req: Request parameters including blockchain(s) to query
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *GetBlockchainStatsResp: Response containing blockchain statistics
 //   - error: Error if the request fails
-func (c *HTTPClient) GetBlockchainStats(ctx context.Context, req GetBlockchainStatsReq) (*GetBlockchainStatsResp, error) {
-	return postWithRetries[GetBlockchainStatsReq, *GetBlockchainStatsResp](ctx, c, "ankr_getBlockchainStats", req, 3)
+func (c *HTTPClient) GetBlockchainStats(ctx context.Context, req GetBlockchainStatsReq, opts ...CallOption) (*GetBlockchainStatsResp, error) {
+	return postWithRetries[GetBlockchainStatsReq, *GetBlockchainStatsResp](ctx, c, MethodGetBlockchainStats, req, opts...)
 }
 
 // GetBlocks retrieves full info of blocks in a range
@@ -278,12 +770,13 @@ func (c *HTTPClient) GetBlockchainStats(ctx context.Context, req GetBlockchainSt
 // Args:
 //   - ctx: Context for cancellation
 //   - req: Request parameters including blockchain, block range, and decode options
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *GetBlocksResp: Response containing block information
 //   - error: Error if the request fails
-func (c *HTTPClient) GetBlocks(ctx context.Context, req GetBlocksReq) (*GetBlocksResp, error) {
-	return postWithRetries[GetBlocksReq, *GetBlocksResp](ctx, c, "ankr_getBlocks", req, 3)
+func (c *HTTPClient) GetBlocks(ctx context.Context, req GetBlocksReq, opts ...CallOption) (*GetBlocksResp, error) {
+	return postWithRetries[GetBlocksReq, *GetBlocksResp](ctx, c, MethodGetBlocks, req, opts...)
 }
 
 // GetLogs retrieves historical data for the specified range of blocks with automatic pagination
@@ -294,11 +787,12 @@ func (c *HTTPClient) GetBlocks(ctx context.Context, req GetBlocksReq) (*GetBlock
 //
 // Args:
 //   - req: Request parameters including blockchain, address filters, block/timestamp range
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *Pages[GetLogsResp]: Paginated response iterator
-func (c *HTTPClient) GetLogs(req GetLogsReq) *Pages[*GetLogsResp] {
-	return newPages(makeNextPageFunc[*GetLogsReq, *GetLogsResp](c, "ankr_getLogs", &req))
+func (c *HTTPClient) GetLogs(req GetLogsReq, opts ...CallOption) *Pages[*GetLogsResp] {
+	return newPages(makeNextPageFunc[*GetLogsReq, *GetLogsResp](c, MethodGetLogs, &req, opts))
 }
 
 // GetTxsByHash retrieves the details of transactions by hash
@@ -309,12 +803,13 @@ func (c *HTTPClient) GetLogs(req GetLogsReq) *Pages[*GetLogsResp] {
 // Args:
 //   - ctx: Context for cancellation
 //   - req: Request parameters including transaction hash and blockchain(s)
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *GetTxsByHashResp: Response containing transaction details
 //   - error: Error if the request fails
-func (c *HTTPClient) GetTxsByHash(ctx context.Context, req GetTxsByHashReq) (*GetTxsByHashResp, error) {
-	return postWithRetries[GetTxsByHashReq, *GetTxsByHashResp](ctx, c, "ankr_getTransactionsByHash", req, 3)
+func (c *HTTPClient) GetTxsByHash(ctx context.Context, req GetTxsByHashReq, opts ...CallOption) (*GetTxsByHashResp, error) {
+	return postWithRetries[GetTxsByHashReq, *GetTxsByHashResp](ctx, c, MethodGetTxsByHash, req, opts...)
 }
 
 // GetTxsByAddress retrieves transactions for a specific address with automatic pagination
@@ -325,11 +820,12 @@ func (c *HTTPClient) GetTxsByHash(ctx context.Context, req GetTxsByHashReq) (*Ge
 //
 // Args:
 //   - req: Request parameters including address, blockchain(s), and range filters
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *Pages[GetTxsByAddressResp]: Paginated response iterator
-func (c *HTTPClient) GetTxsByAddress(req GetTxsByAddressReq) *Pages[*GetTxsByAddressResp] {
-	return newPages(makeNextPageFunc[*GetTxsByAddressReq, *GetTxsByAddressResp](c, "ankr_getTransactionsByAddress", &req))
+func (c *HTTPClient) GetTxsByAddress(req GetTxsByAddressReq, opts ...CallOption) *Pages[*GetTxsByAddressResp] {
+	return newPages(makeNextPageFunc[*GetTxsByAddressReq, *GetTxsByAddressResp](c, MethodGetTxsByAddress, &req, opts))
 }
 
 // GetInteractions retrieves blockchains interacted with a particular wallet
@@ -340,12 +836,13 @@ func (c *HTTPClient) GetTxsByAddress(req GetTxsByAddressReq) *Pages[*GetTxsByAdd
 // Args:
 //   - ctx: Context for cancellation
 //   - req: Request parameters including the wallet address
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *GetInteractionsResp: Response containing list of blockchains
 //   - error: Error if the request fails
-func (c *HTTPClient) GetInteractions(ctx context.Context, req GetInteractionsReq) (*GetInteractionsResp, error) {
-	return postWithRetries[GetInteractionsReq, *GetInteractionsResp](ctx, c, "ankr_getInteractions", req, 3)
+func (c *HTTPClient) GetInteractions(ctx context.Context, req GetInteractionsReq, opts ...CallOption) (*GetInteractionsResp, error) {
+	return postWithRetries[GetInteractionsReq, *GetInteractionsResp](ctx, c, MethodGetInteractions, req, opts...)
 }
 
 // ============================================================================
@@ -359,11 +856,12 @@ func (c *HTTPClient) GetInteractions(ctx context.Context, req GetInteractionsReq
 //
 // Args:
 //   - req: Request parameters including wallet address and blockchain(s)
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *Pages[GetAccountBalanceResp]: Paginated response iterator
-func (c *HTTPClient) GetAccountBalances(req GetAccountBalanceReq) *Pages[*GetAccountBalanceResp] {
-	return newPages(makeNextPageFunc[*GetAccountBalanceReq, *GetAccountBalanceResp](c, "ankr_getAccountBalance", &req))
+func (c *HTTPClient) GetAccountBalances(req GetAccountBalanceReq, opts ...CallOption) *Pages[*GetAccountBalanceResp] {
+	return newPages(makeNextPageFunc[*GetAccountBalanceReq, *GetAccountBalanceResp](c, MethodGetAccountBalance, &req, opts))
 }
 
 // GetCurrencies retrieves info on currencies available for a particular blockchain
@@ -374,12 +872,13 @@ func (c *HTTPClient) GetAccountBalances(req GetAccountBalanceReq) *Pages[*GetAcc
 // Args:
 //   - ctx: Context for cancellation
 //   - req: Request parameters including the blockchain to query
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *GetCurrenciesResp: Response containing list of currencies
 //   - error: Error if the request fails
-func (c *HTTPClient) GetCurrencies(ctx context.Context, req GetCurrenciesReq) (*GetCurrenciesResp, error) {
-	return postWithRetries[GetCurrenciesReq, *GetCurrenciesResp](ctx, c, "ankr_getCurrencies", req, 3)
+func (c *HTTPClient) GetCurrencies(ctx context.Context, req GetCurrenciesReq, opts ...CallOption) (*GetCurrenciesResp, error) {
+	return postWithRetries[GetCurrenciesReq, *GetCurrenciesResp](ctx, c, MethodGetCurrencies, req, opts...)
 }
 
 // GetTokenPrice retrieves the price of a particular token
@@ -390,12 +889,13 @@ func (c *HTTPClient) GetCurrencies(ctx context.Context, req GetCurrenciesReq) (*
 // Args:
 //   - ctx: Context for cancellation
 //   - req: Request parameters including blockchain and optional contract address
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *GetTokenPriceResp: Response containing token price information
 //   - error: Error if the request fails
-func (c *HTTPClient) GetTokenPrice(ctx context.Context, req GetTokenPriceReq) (*GetTokenPriceResp, error) {
-	return postWithRetries[GetTokenPriceReq, *GetTokenPriceResp](ctx, c, "ankr_getTokenPrice", req, 3)
+func (c *HTTPClient) GetTokenPrice(ctx context.Context, req GetTokenPriceReq, opts ...CallOption) (*GetTokenPriceResp, error) {
+	return postWithRetries[GetTokenPriceReq, *GetTokenPriceResp](ctx, c, MethodGetTokenPrice, req, opts...)
 }
 
 // GetTokenHolders retrieves all token holders with automatic pagination
@@ -405,11 +905,12 @@ func (c *HTTPClient) GetTokenPrice(ctx context.Context, req GetTokenPriceReq) (*
 //
 // Args:
 //   - req: Request parameters including contract address and blockchain
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *Pages[GetTokenHoldersResp]: Paginated response iterator
-func (c *HTTPClient) GetTokenHolders(req GetTokenHoldersReq) *Pages[*GetTokenHoldersResp] {
-	return newPages(makeNextPageFunc[*GetTokenHoldersReq, *GetTokenHoldersResp](c, "ankr_getTokenHolders", &req))
+func (c *HTTPClient) GetTokenHolders(req GetTokenHoldersReq, opts ...CallOption) *Pages[*GetTokenHoldersResp] {
+	return newPages(makeNextPageFunc[*GetTokenHoldersReq, *GetTokenHoldersResp](c, MethodGetTokenHolders, &req, opts))
 }
 
 // GetTokenHolderCountHistories retrieves all token holder count data with automatic pagination
@@ -419,11 +920,12 @@ func (c *HTTPClient) GetTokenHolders(req GetTokenHoldersReq) *Pages[*GetTokenHol
 //
 // Args:
 //   - req: Request parameters including contract address and blockchain
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *Pages[GetTokenHoldersCountResp]: Paginated response iterator
-func (c *HTTPClient) GetTokenHolderCountHistories(req GetTokenHoldersCountReq) *Pages[*GetTokenHoldersCountResp] {
-	return newPages(makeNextPageFunc[*GetTokenHoldersCountReq, *GetTokenHoldersCountResp](c, "ankr_getTokenHoldersCount", &req))
+func (c *HTTPClient) GetTokenHolderCountHistories(req GetTokenHoldersCountReq, opts ...CallOption) *Pages[*GetTokenHoldersCountResp] {
+	return newPages(makeNextPageFunc[*GetTokenHoldersCountReq, *GetTokenHoldersCountResp](c, MethodGetTokenHoldersCount, &req, opts))
 }
 
 // GetTokenTransfers retrieves all token transfers with automatic pagination
@@ -434,9 +936,10 @@ func (c *HTTPClient) GetTokenHolderCountHistories(req GetTokenHoldersCountReq) *
 //
 // Args:
 //   - req: Request parameters including addresses, blockchain(s), and range filters
+//   - opts: Per-call options overriding the client configuration
 //
 // Returns:
 //   - *Pages[GetTokenTransfersResp]: Paginated response iterator
-func (c *HTTPClient) GetTokenTransfers(req GetTokenTransfersReq) *Pages[*GetTokenTransfersResp] {
-	return newPages(makeNextPageFunc[*GetTokenTransfersReq, *GetTokenTransfersResp](c, "ankr_getTokenTransfers", &req))
+func (c *HTTPClient) GetTokenTransfers(req GetTokenTransfersReq, opts ...CallOption) *Pages[*GetTokenTransfersResp] {
+	return newPages(makeNextPageFunc[*GetTokenTransfersReq, *GetTokenTransfersResp](c, MethodGetTokenTransfers, &req, opts))
 }
@@ -2,7 +2,15 @@ package ankr
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -633,3 +641,114 @@ func TestGetNFTTransfers(t *testing.T) {
 
 	t.Logf("Total NFT transfers found: %d", totalTransfers)
 }
+
+// newStandInClient starts a local server answering every JSON-RPC call with handler
+// and returns a client pointed at it
+func newStandInClient(t *testing.T, handler http.HandlerFunc, config HTTPClientConfig) *HTTPClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	config.BaseURL = server.URL
+	if config.APIKey == "" && len(config.APIKeys) == 0 {
+		config.APIKey = "test-key"
+	}
+	if config.Logger == nil {
+		config.Logger = slog.New(slog.DiscardHandler)
+	}
+	return NewHTTPClient(&config)
+}
+
+// writeRPCResult writes a successful JSON-RPC response with the given result
+func writeRPCResult(t *testing.T, w http.ResponseWriter, id int64, result any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(RPCRespBody[any]{JSONRPC: JSONRPC, ID: id, Result: result}); err != nil {
+		t.Errorf("failed to write response: %v", err)
+	}
+}
+
+// TestBaseURL tests that requests go to the configured base URL with the API key appended
+func TestBaseURL(t *testing.T) {
+	var gotPath string
+	var gotReq RPCReqBody
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&gotReq); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		writeRPCResult(t, w, gotReq.ID, GetTokenPriceResp{Blockchain: "eth", UsdPrice: "1234.5"})
+	}, HTTPClientConfig{APIKey: "secret"})
+
+	resp, err := client.GetTokenPrice(context.Background(), GetTokenPriceReq{Blockchain: ChainEthereum})
+	if err != nil {
+		t.Fatalf("GetTokenPrice failed: %v", err)
+	}
+	if gotPath != "/secret" {
+		t.Errorf("Expected path /secret, got %s", gotPath)
+	}
+	if gotReq.Method != MethodGetTokenPrice {
+		t.Errorf("Expected method %s, got %s", MethodGetTokenPrice, gotReq.Method)
+	}
+	if resp.UsdPrice != "1234.5" {
+		t.Errorf("Expected price 1234.5, got %s", resp.UsdPrice)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// TestCustomTransport tests that a configured transport is used for requests
+func TestCustomTransport(t *testing.T) {
+	var calls atomic.Int32
+	client := NewHTTPClient(&HTTPClientConfig{
+		APIKey: "secret",
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			calls.Add(1)
+			body := `{"jsonrpc":"2.0","id":1,"result":{"blockchains":["eth","bsc"]}}`
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    r,
+			}, nil
+		}),
+	})
+
+	resp, err := client.GetInteractions(context.Background(), GetInteractionsReq{Address: "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"})
+	if err != nil {
+		t.Fatalf("GetInteractions failed: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 call through the transport, got %d", calls.Load())
+	}
+	if len(resp.Blockchains) != 2 {
+		t.Errorf("Expected 2 blockchains, got %d", len(resp.Blockchains))
+	}
+}
+
+// TestMethodTimeouts tests that a per-method default timeout cancels slow calls
+func TestMethodTimeouts(t *testing.T) {
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// the body has to be consumed for the server to notice the client going away
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}, HTTPClientConfig{
+		MethodTimeouts: map[string]time.Duration{MethodGetCurrencies: 50 * time.Millisecond},
+	})
+
+	start := time.Now()
+	_, err := post[GetCurrenciesReq, *GetCurrenciesResp](context.Background(), client, MethodGetCurrencies, GetCurrenciesReq{Blockchain: ChainEthereum})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the call to time out quickly, took %v", elapsed)
+	}
+}
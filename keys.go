@@ -0,0 +1,241 @@
+package ankr
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of a key of the key pool
+const (
+	// DefaultKeyRateLimit is the number of requests a key may send per DefaultKeyRateInterval
+	DefaultKeyRateLimit = 1000
+
+	// DefaultKeyRateInterval is the window of a key's rate limit
+	DefaultKeyRateInterval = time.Minute
+
+	// DefaultKeyParkDuration is how long a rate limited key is parked without a Retry-After header
+	DefaultKeyParkDuration = time.Minute
+
+	// DefaultKeyQuotaParkDuration is how long a key with an exhausted quota is parked
+	DefaultKeyQuotaParkDuration = time.Hour
+)
+
+// APIKey is one key of the client's key pool
+type APIKey struct {
+	Key string
+
+	// RateLimit is the number of requests the key may send per RateLimitInterval (default: DefaultKeyRateLimit)
+	RateLimit int
+
+	// RateLimitInterval is the window of RateLimit (default: DefaultKeyRateInterval)
+	RateLimitInterval time.Duration
+}
+
+// KeySelection decides which key of the pool sends the next request
+type KeySelection string
+
+const (
+	// KeyRoundRobin cycles through the available keys
+	KeyRoundRobin KeySelection = "round-robin"
+
+	// KeyLeastLoaded picks the available key with the lowest share of its rate limit in use
+	KeyLeastLoaded KeySelection = "least-loaded"
+)
+
+// KeyStats is a snapshot of the usage of a key of the pool
+type KeyStats struct {
+	// Key is the API key with everything but its first 4 characters redacted
+	Key string
+
+	// Requests is the number of requests sent with the key
+	Requests int64
+
+	// RateLimited is the number of requests rejected because of rate limiting
+	RateLimited int64
+
+	// QuotaExceeded is the number of requests rejected because of an exhausted quota
+	QuotaExceeded int64
+
+	// InFlight is the number of requests currently sent with the key
+	InFlight int64
+
+	// ParkedUntil is when the key becomes available again, zero if it isn't parked
+	ParkedUntil time.Time
+}
+
+// keyPool holds the API keys of a client, each with its own rate limiter and usage counters
+type keyPool struct {
+	keys              []*poolKey
+	selection         KeySelection
+	parkDuration      time.Duration
+	quotaParkDuration time.Duration
+	next              atomic.Uint64
+}
+
+type poolKey struct {
+	key     string
+	limiter *SimpleLimiter
+	limit   int
+
+	requests      atomic.Int64
+	rateLimited   atomic.Int64
+	quotaExceeded atomic.Int64
+	inFlight      atomic.Int64
+
+	mu          sync.Mutex
+	parkedUntil time.Time
+}
+
+// newKeyPool creates the key pool of config, a client without keys gets a single empty key
+func newKeyPool(config *HTTPClientConfig) *keyPool {
+	keys := config.APIKeys
+	if config.APIKey != "" || len(keys) == 0 {
+		keys = append([]APIKey{{Key: config.APIKey}}, keys...)
+	}
+
+	pool := &keyPool{
+		selection:         config.KeySelection,
+		parkDuration:      config.KeyParkDuration,
+		quotaParkDuration: config.KeyQuotaParkDuration,
+	}
+	if pool.selection == "" {
+		pool.selection = KeyRoundRobin
+	}
+	if pool.parkDuration <= 0 {
+		pool.parkDuration = DefaultKeyParkDuration
+	}
+	if pool.quotaParkDuration <= 0 {
+		pool.quotaParkDuration = DefaultKeyQuotaParkDuration
+	}
+
+	for _, key := range keys {
+		limit, interval := key.RateLimit, key.RateLimitInterval
+		if limit <= 0 {
+			limit = DefaultKeyRateLimit
+		}
+		if interval <= 0 {
+			interval = DefaultKeyRateInterval
+		}
+		limiter := NewSimpleLimiter(interval, limit)
+		if config.BulkShare != 0 {
+			limiter.SetBulkShare(config.BulkShare)
+		}
+		pool.keys = append(pool.keys, &poolKey{
+			key:     key.Key,
+			limiter: limiter,
+			limit:   limit,
+		})
+	}
+	return pool
+}
+
+// pick returns the key that sends the next request, skipping the keys in tried
+//
+// Parked keys are only used when every other key is parked too,
+// in which case the one unparked the soonest is returned.
+// ok is false once every key has been tried.
+func (p *keyPool) pick(tried map[*poolKey]bool) (key *poolKey, ok bool) {
+	now := time.Now()
+	var available []*poolKey
+	var soonest *poolKey
+	for _, k := range p.keys {
+		if tried[k] {
+			continue
+		}
+		until := k.parkedUntilTime()
+		if !until.After(now) {
+			available = append(available, k)
+		} else if soonest == nil || until.Before(soonest.parkedUntilTime()) {
+			soonest = k
+		}
+	}
+	if len(available) == 0 {
+		return soonest, soonest != nil
+	}
+
+	if p.selection == KeyLeastLoaded {
+		key = available[0]
+		for _, k := range available[1:] {
+			if k.load() < key.load() {
+				key = k
+			}
+		}
+		return key, true
+	}
+	return available[(p.next.Add(1)-1)%uint64(len(available))], true
+}
+
+// observe parks key if err shows it is rate limited or out of quota, and reports whether it was parked
+func (p *keyPool) observe(key *poolKey, err error) bool {
+	switch {
+	case errors.Is(err, ErrQuotaExceeded):
+		key.quotaExceeded.Add(1)
+		key.park(p.quotaParkDuration)
+	case errors.Is(err, ErrRateLimited):
+		key.rateLimited.Add(1)
+		d, ok := RetryAfter(err)
+		if !ok {
+			d = p.parkDuration
+		}
+		key.park(d)
+	default:
+		return false
+	}
+	return true
+}
+
+// load is the share of the key's rate limit currently in use
+func (k *poolKey) load() float64 {
+	return float64(k.limiter.inUse()+int(k.inFlight.Load())) / float64(k.limit)
+}
+
+func (k *poolKey) park(d time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if until := time.Now().Add(d); until.After(k.parkedUntil) {
+		k.parkedUntil = until
+	}
+}
+
+func (k *poolKey) parkedUntilTime() time.Time {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.parkedUntil
+}
+
+func (k *poolKey) parked() bool {
+	return k.parkedUntilTime().After(time.Now())
+}
+
+func (k *poolKey) stats() KeyStats {
+	stats := KeyStats{
+		Key:           maskKey(k.key),
+		Requests:      k.requests.Load(),
+		RateLimited:   k.rateLimited.Load(),
+		QuotaExceeded: k.quotaExceeded.Load(),
+		InFlight:      k.inFlight.Load(),
+	}
+	if until := k.parkedUntilTime(); until.After(time.Now()) {
+		stats.ParkedUntil = until
+	}
+	return stats
+}
+
+// maskKey keeps the first 4 characters of key so that keys can be told apart in stats and logs
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return redactedKey
+	}
+	return key[:4] + "..." + redactedKey
+}
+
+// KeyStats returns the usage of every key of the pool, in configuration order
+func (c *HTTPClient) KeyStats() []KeyStats {
+	stats := make([]KeyStats, len(c.keys.keys))
+	for i, k := range c.keys.keys {
+		stats[i] = k.stats()
+	}
+	return stats
+}
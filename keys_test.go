@@ -0,0 +1,78 @@
+package ankr
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestKeyPool tests round-robin selection and parking of rate limited and exhausted keys
+func TestKeyPool(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		mu.Lock()
+		calls[key]++
+		mu.Unlock()
+		switch key {
+		case "key-limited":
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+		case "key-empty":
+			http.Error(w, "quota exceeded", http.StatusPaymentRequired)
+		default:
+			writeRPCResult(t, w, 1, GetCurrenciesResp{})
+		}
+	}, HTTPClientConfig{
+		APIKeys:     []APIKey{{Key: "key-ok-1"}, {Key: "key-limited"}, {Key: "key-empty"}, {Key: "key-ok-2"}},
+		RetryPolicy: NoRetry(),
+	})
+
+	ctx := context.Background()
+	for range 6 {
+		if _, err := client.GetCurrencies(ctx, GetCurrenciesReq{Blockchain: ChainEthereum}); err != nil {
+			t.Fatalf("GetCurrencies failed: %v", err)
+		}
+	}
+
+	if calls["key-limited"] != 1 || calls["key-empty"] != 1 {
+		t.Errorf("Expected failing keys to be parked after their first request, got %v", calls)
+	}
+	if calls["key-ok-1"]+calls["key-ok-2"] != 6 || calls["key-ok-1"] == 0 || calls["key-ok-2"] == 0 {
+		t.Errorf("Expected every call to succeed on the healthy keys in turn, got %v", calls)
+	}
+
+	stats := client.KeyStats()
+	if len(stats) != 4 {
+		t.Fatalf("Expected 4 keys, got %d", len(stats))
+	}
+	if stats[1].RateLimited != 1 || time.Until(stats[1].ParkedUntil) < 20*time.Second {
+		t.Errorf("Expected the rate limited key to be parked for Retry-After, got %+v", stats[1])
+	}
+	if stats[2].QuotaExceeded != 1 || time.Until(stats[2].ParkedUntil) < 50*time.Minute {
+		t.Errorf("Expected the exhausted key to be parked for the quota duration, got %+v", stats[2])
+	}
+	if !stats[0].ParkedUntil.IsZero() || stats[0].Key != "key-...REDACTED" {
+		t.Errorf("Unexpected stats of a healthy key: %+v", stats[0])
+	}
+}
+
+// TestKeyPoolLeastLoaded tests that the key with the lowest share of its rate limit in use is picked
+func TestKeyPoolLeastLoaded(t *testing.T) {
+	pool := newKeyPool(&HTTPClientConfig{
+		APIKeys:      []APIKey{{Key: "small", RateLimit: 10}, {Key: "large", RateLimit: 100}},
+		KeySelection: KeyLeastLoaded,
+	})
+	for range 11 {
+		key, _ := pool.pick(nil)
+		key.limiter.Wait(context.Background())
+	}
+	small, large := pool.keys[0], pool.keys[1]
+	if small.limiter.inUse() != 1 || large.limiter.inUse() != 10 {
+		t.Errorf("Expected the keys to be loaded in proportion to their limits, got %d and %d", small.limiter.inUse(), large.limiter.inUse())
+	}
+}
@@ -0,0 +1,82 @@
+package ankr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrClientClosed is returned for calls made after HTTPClient.Close
+var ErrClientClosed = errors.New("ankr: client closed")
+
+// lifecycle tracks the calls and scans in progress, so that Close can drain them
+type lifecycle struct {
+	mu     sync.Mutex
+	closed bool
+	active int
+	idle   chan struct{} // closed once nothing is active after closing
+}
+
+// enter registers a call or scan, done must be called once it has ended
+func (l *lifecycle) enter() (done func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, ErrClientClosed
+	}
+	l.active++
+	var once sync.Once
+	return func() { once.Do(l.leave) }, nil
+}
+
+func (l *lifecycle) leave() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	if l.closed && l.active == 0 {
+		close(l.idle)
+	}
+}
+
+// close rejects new calls and scans and returns a channel closed once the active ones have ended
+func (l *lifecycle) close() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.closed {
+		l.closed = true
+		l.idle = make(chan struct{})
+		if l.active == 0 {
+			close(l.idle)
+		}
+	}
+	return l.idle
+}
+
+func (l *lifecycle) activeCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active
+}
+
+// Close shuts the client down
+//
+// New calls, batches, Pages scans and streams fail with ErrClientClosed right away.
+// Calls in flight, endpoint probes, and scans started before Close, go on until they end or ctx is done:
+// a scan is active from its first page until its last one, its first error or Pages.Stop.
+// The idle connections of the transport are closed either way, the rate limiters of the keys
+// only once nothing is active, so that calls still waiting for them stay throttled.
+// The error is ctx's if calls were still active when it was done, Close can then be called again.
+func (c *HTTPClient) Close(ctx context.Context) error {
+	defer c.httpClient.CloseIdleConnections()
+	select {
+	case <-c.lifecycle.close():
+	case <-ctx.Done():
+		return fmt.Errorf("ankr: closed with %d call(s) still active: %w", c.lifecycle.activeCount(), ctx.Err())
+	}
+
+	for _, key := range c.keys.keys {
+		key.limiter.Close()
+	}
+	return nil
+}
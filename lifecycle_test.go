@@ -0,0 +1,228 @@
+package ankr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCloseDrainsCalls tests that Close rejects new calls and waits for the calls in flight
+func TestCloseDrainsCalls(t *testing.T) {
+	received, release := make(chan struct{}, 1), make(chan struct{})
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+		writeRPCResult(t, w, 1, GetTokenPriceResp{UsdPrice: "1"})
+	}, HTTPClientConfig{})
+	req := GetTokenPriceReq{Blockchain: ChainEthereum}
+
+	callErr := make(chan error)
+	go func() {
+		_, err := client.GetTokenPrice(context.Background(), req)
+		callErr <- err
+	}()
+	<-received
+
+	closeErr := make(chan error)
+	go func() {
+		closeErr <- client.Close(context.Background())
+	}()
+	for !client.lifecycle.closing() {
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err := client.GetTokenPrice(context.Background(), req)
+	if !errors.Is(err, ErrClientClosed) || IsRetryable(err) {
+		t.Errorf("Expected a non-retryable ErrClientClosed, got %v", err)
+	}
+	select {
+	case err := <-closeErr:
+		t.Fatalf("Expected Close to wait for the call in flight, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-callErr; err != nil {
+		t.Errorf("Expected the call in flight to succeed, got %v", err)
+	}
+	if err := <-closeErr; err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+// TestCloseDeadline tests that Close gives up waiting once its context is done
+func TestCloseDeadline(t *testing.T) {
+	received, release := make(chan struct{}, 1), make(chan struct{})
+	defer close(release)
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-release
+	}, HTTPClientConfig{RetryPolicy: NoRetry()})
+
+	go client.GetTokenPrice(context.Background(), GetTokenPriceReq{Blockchain: ChainEthereum})
+	<-received
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Close to time out, got %v", err)
+	}
+}
+
+// TestCloseDeadlineThrottles tests that calls waiting for the rate limiter stay throttled after Close times out
+func TestCloseDeadlineThrottles(t *testing.T) {
+	var requests atomic.Int32
+	received, release := make(chan struct{}, 1), make(chan struct{})
+	defer close(release)
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+		<-release
+	}, HTTPClientConfig{
+		APIKeys:     []APIKey{{Key: "test-key", RateLimit: 1, RateLimitInterval: time.Hour}},
+		RetryPolicy: NoRetry(),
+	})
+	req := GetTokenPriceReq{Blockchain: ChainEthereum}
+
+	go client.GetTokenPrice(context.Background(), req)
+	<-received
+	waiting, cancelWaiting := context.WithCancel(context.Background())
+	defer cancelWaiting()
+	for range 3 {
+		go client.GetTokenPrice(waiting, req)
+	}
+	for client.lifecycle.activeCount() < 4 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Close to time out, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Expected the waiting calls to stay throttled, got %d requests", n)
+	}
+}
+
+// TestCloseWaitsForProbes tests that Close waits for the endpoint probes in flight
+func TestCloseWaitsForProbes(t *testing.T) {
+	probing, release := make(chan struct{}, 1), make(chan struct{})
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeRPCResult(t, w, 1, GetCurrenciesResp{})
+	}))
+	t.Cleanup(secondary.Close)
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req RPCReqBody
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if req.Method == MethodGetBlockchainStats {
+			probing <- struct{}{}
+			<-release
+		}
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}, HTTPClientConfig{
+		Endpoints:                []string{secondary.URL},
+		EndpointFailureThreshold: 1,
+		RetryPolicy:              NoRetry(),
+	})
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := client.GetCurrencies(ctx, GetCurrenciesReq{Blockchain: ChainEthereum}); err != nil {
+			t.Fatalf("GetCurrencies failed: %v", err)
+		}
+	}
+	<-probing
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := client.Close(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Close to wait for the probe, got %v", err)
+	}
+	close(release)
+	if err := client.Close(ctx); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+// TestCloseDrainsScans tests that scans started before Close can fetch their remaining pages
+func TestCloseDrainsScans(t *testing.T) {
+	var requests int
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		next := ""
+		if requests == 1 {
+			next = "page-2"
+		}
+		writeRPCResult(t, w, 1, GetLogsResp{NextPageToken: next})
+	}, HTTPClientConfig{})
+	ctx := context.Background()
+
+	pages := client.GetLogs(GetLogsReq{Blockchain: ChainEthereum})
+	if _, err := pages.Next(ctx); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+
+	closeErr := make(chan error)
+	go func() {
+		closeErr <- client.Close(ctx)
+	}()
+	for !client.lifecycle.closing() {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := client.GetLogs(GetLogsReq{Blockchain: ChainEthereum}).Next(ctx); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Expected new scans to be rejected, got %v", err)
+	}
+	if _, err := pages.Next(ctx); err != nil {
+		t.Errorf("Expected the active scan to go on, got %v", err)
+	}
+	if pages.HasNext() {
+		t.Error("Expected the scan to be over")
+	}
+	if err := <-closeErr; err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+// TestCloseStoppedScan tests that Close doesn't wait for scans stopped before their last page
+func TestCloseStoppedScan(t *testing.T) {
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeRPCResult(t, w, 1, GetLogsResp{NextPageToken: "next"})
+	}, HTTPClientConfig{})
+	ctx := context.Background()
+
+	pages := client.GetLogs(GetLogsReq{Blockchain: ChainEthereum})
+	if _, err := pages.Next(ctx); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	pages.Stop()
+	pages.Stop()
+	if pages.HasNext() {
+		t.Error("Expected a stopped scan to have no next page")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := client.Close(ctx); err != nil {
+		t.Errorf("Expected Close not to wait for the stopped scan, got %v", err)
+	}
+}
+
+// closing reports whether Close has been called
+func (l *lifecycle) closing() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
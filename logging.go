@@ -0,0 +1,29 @@
+package ankr
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// redactedKey replaces API keys in log lines and errors
+const redactedKey = "REDACTED"
+
+// redact scrubs the client's API keys from s
+func (c *HTTPClient) redact(s string) string {
+	for _, k := range c.keys.keys {
+		if k.key != "" {
+			s = strings.ReplaceAll(s, k.key, redactedKey)
+		}
+	}
+	return s
+}
+
+// redactError scrubs the client's API keys from the URL embedded in err by net/http
+func (c *HTTPClient) redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = c.redact(urlErr.URL)
+	}
+	return err
+}
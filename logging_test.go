@@ -0,0 +1,45 @@
+package ankr
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAPIKeyRedaction tests that the API key never shows up in logs or returned errors
+func TestAPIKeyRedaction(t *testing.T) {
+	const apiKey = "super-secret-key"
+
+	// A closed server makes every request fail with a *url.Error embedding the URL
+	server := httptest.NewServer(nil)
+	server.Close()
+
+	var logs bytes.Buffer
+	client := NewHTTPClient(&HTTPClientConfig{
+		APIKey:      apiKey,
+		BaseURL:     server.URL,
+		Logger:      slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		RetryPolicy: &ExponentialBackoff{MaxAttempts: 2, InitialDelay: time.Millisecond},
+	})
+
+	_, err := client.GetCurrencies(context.Background(), GetCurrenciesReq{Blockchain: ChainEthereum})
+	if err == nil {
+		t.Fatal("Expected the request to fail")
+	}
+	if strings.Contains(err.Error(), apiKey) {
+		t.Errorf("Expected the API key to be redacted from the error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), redactedKey) {
+		t.Errorf("Expected the redacted URL in the error, got %v", err)
+	}
+	if !strings.Contains(logs.String(), "retrying") {
+		t.Errorf("Expected a retry log line, got %q", logs.String())
+	}
+	if strings.Contains(logs.String(), apiKey) {
+		t.Errorf("Expected the API key to be redacted from logs, got %q", logs.String())
+	}
+}
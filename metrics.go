@@ -0,0 +1,280 @@
+package ankr
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics receives instrumentation events from the client
+//
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ObserveRequest is called once for every HTTP request sent
+	ObserveRequest(stats RequestStats)
+
+	// ObserveRetry is called before a failed call is retried
+	ObserveRetry(method string, attempt int)
+
+	// ObserveLimiterWait is called with the time a call spent blocked in the rate limiter
+	ObserveLimiterWait(method string, waited time.Duration)
+
+	// ObserveHedge is called once a hedged call is over, won is true if the hedge answered first
+	ObserveHedge(method string, won bool)
+}
+
+// batchMethod is the method name reported for batch requests
+const batchMethod = "batch"
+
+// RequestStats describes a single HTTP request sent by the client
+type RequestStats struct {
+	// Method is the JSON-RPC method, or "batch" for batch requests
+	Method string
+
+	// StatusCode is the HTTP status code, 0 if no response was received
+	StatusCode int
+
+	// RPCErrorCode is the code of the JSON-RPC error in the response, 0 if none
+	RPCErrorCode int
+
+	// Duration is the time from sending the request to reading the whole response,
+	// or to receiving the response headers for streamed calls
+	Duration time.Duration
+
+	// BytesReceived is the size of the response body
+	// Successful streamed calls are observed once their body is closed, with the bytes read until then
+	BytesReceived int
+
+	// Err is the error of the request, if any
+	Err error
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(RequestStats)              {}
+func (noopMetrics) ObserveRetry(string, int)                 {}
+func (noopMetrics) ObserveLimiterWait(string, time.Duration) {}
+func (noopMetrics) ObserveHedge(string, bool)                {}
+
+// DefaultLatencyBuckets are the histogram buckets of PrometheusMetrics, in seconds
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// PrometheusMetrics is a dependency-free Metrics implementation
+// that serves the collected metrics in the Prometheus text format
+//
+// It exposes the following metrics, all labeled by method:
+//   - ankr_requests_total{status}: HTTP requests by status code ("none" if no response)
+//   - ankr_rpc_errors_total{code}: JSON-RPC errors by code
+//   - ankr_request_duration_seconds: histogram of request latency
+//   - ankr_response_bytes_total: response body bytes received
+//   - ankr_retries_total: retried calls
+//   - ankr_limiter_wait_seconds: histogram of the time spent blocked in the rate limiter
+//   - ankr_hedges_total{outcome}: hedged calls by outcome ("won" if the hedge answered first, "lost" otherwise)
+type PrometheusMetrics struct {
+	mu          sync.Mutex
+	buckets     []float64
+	requests    map[[2]string]uint64
+	rpcErrors   map[[2]string]uint64
+	latency     map[string]*histogram
+	bytes       map[string]uint64
+	retries     map[string]uint64
+	limiterWait map[string]*histogram
+	hedges      map[[2]string]uint64
+}
+
+// NewPrometheusMetrics creates an empty metrics registry
+// buckets are the histogram upper bounds in seconds (default: DefaultLatencyBuckets)
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &PrometheusMetrics{
+		buckets:     buckets,
+		requests:    make(map[[2]string]uint64),
+		rpcErrors:   make(map[[2]string]uint64),
+		latency:     make(map[string]*histogram),
+		bytes:       make(map[string]uint64),
+		retries:     make(map[string]uint64),
+		limiterWait: make(map[string]*histogram),
+		hedges:      make(map[[2]string]uint64),
+	}
+}
+
+// ObserveRequest implements Metrics
+func (m *PrometheusMetrics) ObserveRequest(stats RequestStats) {
+	status := "none"
+	if stats.StatusCode != 0 {
+		status = strconv.Itoa(stats.StatusCode)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[2]string{stats.Method, status}]++
+	if stats.RPCErrorCode != 0 {
+		m.rpcErrors[[2]string{stats.Method, strconv.Itoa(stats.RPCErrorCode)}]++
+	}
+	m.histogram(m.latency, stats.Method).observe(stats.Duration.Seconds())
+	m.bytes[stats.Method] += uint64(stats.BytesReceived)
+}
+
+// ObserveRetry implements Metrics
+func (m *PrometheusMetrics) ObserveRetry(method string, attempt int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[method]++
+}
+
+// ObserveLimiterWait implements Metrics
+func (m *PrometheusMetrics) ObserveLimiterWait(method string, waited time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.histogram(m.limiterWait, method).observe(waited.Seconds())
+}
+
+// ObserveHedge implements Metrics
+func (m *PrometheusMetrics) ObserveHedge(method string, won bool) {
+	outcome := "lost"
+	if won {
+		outcome = "won"
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hedges[[2]string{method, outcome}]++
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	writeHeader(cw, "ankr_requests_total", "counter", "HTTP requests sent by method and status code.")
+	for _, key := range sortedKeys(m.requests, compareLabels) {
+		fmt.Fprintf(cw, "ankr_requests_total{method=%s,status=%s} %d\n", quoteLabel(key[0]), quoteLabel(key[1]), m.requests[key])
+	}
+
+	writeHeader(cw, "ankr_rpc_errors_total", "counter", "JSON-RPC errors by method and error code.")
+	for _, key := range sortedKeys(m.rpcErrors, compareLabels) {
+		fmt.Fprintf(cw, "ankr_rpc_errors_total{method=%s,code=%s} %d\n", quoteLabel(key[0]), quoteLabel(key[1]), m.rpcErrors[key])
+	}
+
+	writeHeader(cw, "ankr_request_duration_seconds", "histogram", "HTTP request latency by method.")
+	for _, method := range sortedKeys(m.latency, strings.Compare) {
+		m.latency[method].write(cw, "ankr_request_duration_seconds", method, m.buckets)
+	}
+
+	writeHeader(cw, "ankr_response_bytes_total", "counter", "Response body bytes received by method.")
+	for _, method := range sortedKeys(m.bytes, strings.Compare) {
+		fmt.Fprintf(cw, "ankr_response_bytes_total{method=%s} %d\n", quoteLabel(method), m.bytes[method])
+	}
+
+	writeHeader(cw, "ankr_retries_total", "counter", "Retried calls by method.")
+	for _, method := range sortedKeys(m.retries, strings.Compare) {
+		fmt.Fprintf(cw, "ankr_retries_total{method=%s} %d\n", quoteLabel(method), m.retries[method])
+	}
+
+	writeHeader(cw, "ankr_limiter_wait_seconds", "histogram", "Time spent blocked in the rate limiter by method.")
+	for _, method := range sortedKeys(m.limiterWait, strings.Compare) {
+		m.limiterWait[method].write(cw, "ankr_limiter_wait_seconds", method, m.buckets)
+	}
+
+	writeHeader(cw, "ankr_hedges_total", "counter", "Hedged calls by method and outcome.")
+	for _, key := range sortedKeys(m.hedges, compareLabels) {
+		fmt.Fprintf(cw, "ankr_hedges_total{method=%s,outcome=%s} %d\n", quoteLabel(key[0]), quoteLabel(key[1]), m.hedges[key])
+	}
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// histogram returns the histogram of method in hs, creating it if needed
+func (m *PrometheusMetrics) histogram(hs map[string]*histogram, method string) *histogram {
+	h, ok := hs[method]
+	if !ok {
+		h = &histogram{bounds: m.buckets, counts: make([]uint64, len(m.buckets))}
+		hs[method] = h
+	}
+	return h
+}
+
+// histogram is a cumulative Prometheus histogram
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) write(w io.Writer, name, method string, bounds []float64) {
+	label := quoteLabel(method)
+	for i, bound := range bounds {
+		fmt.Fprintf(w, "%s_bucket{method=%s,le=\"%s\"} %d\n", name, label, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{method=%s,le=\"+Inf\"} %d\n", name, label, h.count)
+	fmt.Fprintf(w, "%s_sum{method=%s} %s\n", name, label, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count{method=%s} %d\n", name, label, h.count)
+}
+
+func writeHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// quoteLabel quotes a label value with the escaping of the Prometheus text format
+func quoteLabel(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return `"` + v + `"`
+}
+
+func compareLabels(a, b [2]string) int {
+	return cmp.Or(strings.Compare(a[0], b[0]), strings.Compare(a[1], b[1]))
+}
+
+func sortedKeys[K comparable, V any](m map[K]V, compare func(a, b K) int) []K {
+	return slices.SortedFunc(maps.Keys(m), compare)
+}
+
+// countingWriter counts written bytes and keeps the first write error
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
@@ -0,0 +1,87 @@
+package ankr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPrometheusMetrics tests that calls are counted and exported in the Prometheus text format
+func TestPrometheusMetrics(t *testing.T) {
+	var calls atomic.Int32
+	metrics := NewPrometheusMetrics()
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"internal error"}}`))
+		default:
+			writeRPCResult(t, w, 1, GetTokenPriceResp{UsdPrice: "1"})
+		}
+	}, HTTPClientConfig{
+		Metrics:     metrics,
+		RetryPolicy: &ExponentialBackoff{InitialDelay: time.Millisecond},
+	})
+
+	if _, err := client.GetTokenPrice(context.Background(), GetTokenPriceReq{Blockchain: ChainEthereum}); err != nil {
+		t.Fatalf("GetTokenPrice failed: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := recorder.Body.String()
+
+	for _, want := range []string{
+		`# TYPE ankr_requests_total counter`,
+		`ankr_requests_total{method="ankr_getTokenPrice",status="200"} 2`,
+		`ankr_requests_total{method="ankr_getTokenPrice",status="502"} 1`,
+		`ankr_rpc_errors_total{method="ankr_getTokenPrice",code="-32603"} 1`,
+		`ankr_request_duration_seconds_count{method="ankr_getTokenPrice"} 3`,
+		`ankr_request_duration_seconds_bucket{method="ankr_getTokenPrice",le="+Inf"} 3`,
+		`ankr_retries_total{method="ankr_getTokenPrice"} 2`,
+		`ankr_limiter_wait_seconds_count{method="ankr_getTokenPrice"} 3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+// TestMetricsFailover tests that every request sent while failing over is observed
+func TestMetricsFailover(t *testing.T) {
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeRPCResult(t, w, 1, GetTokenPriceResp{UsdPrice: "1"})
+	}))
+	t.Cleanup(secondary.Close)
+
+	metrics := NewPrometheusMetrics()
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, HTTPClientConfig{
+		Endpoints:   []string{secondary.URL},
+		Metrics:     metrics,
+		RetryPolicy: NoRetry(),
+	})
+
+	if _, err := client.GetTokenPrice(context.Background(), GetTokenPriceReq{Blockchain: ChainEthereum}); err != nil {
+		t.Fatalf("GetTokenPrice failed: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := recorder.Body.String()
+	for _, want := range []string{
+		`ankr_requests_total{method="ankr_getTokenPrice",status="503"} 1`,
+		`ankr_requests_total{method="ankr_getTokenPrice",status="200"} 1`,
+		`ankr_request_duration_seconds_count{method="ankr_getTokenPrice"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ankr
+
+import (
+	"context"
+	"net/http"
+)
+
+// RPCRequest is a single JSON-RPC call as seen by middlewares
+type RPCRequest struct {
+	// Method is the JSON-RPC method name
+	Method string
+
+	// Params are the call params after ApplyDefaults
+	// Middlewares may replace them before calling the next handler
+	Params any
+
+	// Header is sent with the HTTP request, e.g. for auth or tagging headers
+	Header http.Header
+
+	// Priority is the class of the call when waiting for the rate limiter, see WithPriority
+	Priority Priority
+}
+
+// RPCResponse is the raw response of a JSON-RPC call as seen by middlewares
+type RPCResponse struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// Header is the header of the HTTP response
+	Header http.Header
+
+	// Body is the raw JSON-RPC response body
+	// It is decoded into the typed result once the whole chain has returned
+	Body []byte
+}
+
+// Handler sends a JSON-RPC call and returns its raw response
+//
+// A JSON-RPC error object in the response is returned as *RPCError together with the response,
+// a non-200 status as *HTTPStatusError together with the response.
+type Handler func(ctx context.Context, req *RPCRequest) (*RPCResponse, error)
+
+// Middleware wraps every JSON-RPC call made by the client
+//
+// A middleware can inspect or modify the request before calling next,
+// inspect or modify the response and error returned by next,
+// or short-circuit the call by returning without calling next.
+// Rate limiting happens after the last middleware, so short-circuited calls don't consume tokens.
+type Middleware func(next Handler) Handler
+
+// chainMiddlewares wraps handler with middlewares, the first middleware being the outermost
+func chainMiddlewares(handler Handler, middlewares []Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,100 @@
+package ankr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+// TestMiddlewares tests that middlewares run in order and see defaulted params, responses and errors
+func TestMiddlewares(t *testing.T) {
+	var order []string
+	var seenParams any
+	var seenStatus int
+	var gotTag string
+
+	tag := func(next Handler) Handler {
+		return func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+			order = append(order, "tag")
+			req.Header.Set("X-Request-Tag", "backfill")
+			return next(ctx, req)
+		}
+	}
+	audit := func(next Handler) Handler {
+		return func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+			order = append(order, "audit")
+			seenParams = req.Params
+			resp, err := next(ctx, req)
+			if resp != nil {
+				seenStatus = resp.StatusCode
+			}
+			return resp, err
+		}
+	}
+
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotTag = r.Header.Get("X-Request-Tag")
+		writeRPCResult(t, w, 1, GetNFTHoldersResp{Holders: []string{"0x1"}})
+	}, HTTPClientConfig{Middlewares: []Middleware{tag, audit}})
+
+	_, err := client.GetNFTHolders(GetNFTHoldersReq{Blockchain: ChainEthereum, ContractAddress: "0xBC4CA0EdA7647A8aB7C2061c2E118A18a936f13D"}).Next(context.Background())
+	if err != nil {
+		t.Fatalf("GetNFTHolders failed: %v", err)
+	}
+
+	if !slices.Equal(order, []string{"tag", "audit"}) {
+		t.Errorf("Expected middlewares to run in order, got %v", order)
+	}
+	if gotTag != "backfill" {
+		t.Errorf("Expected the tag header to be sent, got %q", gotTag)
+	}
+	params, ok := seenParams.(*GetNFTHoldersReq)
+	if !ok || params.PageSize != 1000 {
+		t.Errorf("Expected params with defaults applied, got %+v", seenParams)
+	}
+	if seenStatus != http.StatusOK {
+		t.Errorf("Expected the middleware to see status 200, got %d", seenStatus)
+	}
+}
+
+// TestMiddlewareShortCircuit tests that a middleware can answer a call without sending it
+func TestMiddlewareShortCircuit(t *testing.T) {
+	var requests atomic.Int32
+	stub := func(next Handler) Handler {
+		return func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+			if req.Method != MethodGetTokenPrice {
+				return next(ctx, req)
+			}
+			return &RPCResponse{
+				StatusCode: http.StatusOK,
+				Body:       []byte(`{"jsonrpc":"2.0","id":1,"result":{"blockchain":"eth","usdPrice":"42"}}`),
+			}, nil
+		}
+	}
+
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid params"}}`))
+	}, HTTPClientConfig{Middlewares: []Middleware{stub}, RetryPolicy: NoRetry()})
+
+	resp, err := client.GetTokenPrice(context.Background(), GetTokenPriceReq{Blockchain: ChainEthereum})
+	if err != nil {
+		t.Fatalf("GetTokenPrice failed: %v", err)
+	}
+	if resp.UsdPrice != "42" {
+		t.Errorf("Expected the stubbed price, got %s", resp.UsdPrice)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("Expected no HTTP request, got %d", requests.Load())
+	}
+
+	// Calls passed through still surface RPC errors to the middleware chain
+	_, err = client.GetCurrencies(context.Background(), GetCurrenciesReq{Blockchain: ChainEthereum})
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Errorf("Expected *RPCError, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package ankr
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyHeader is the header carrying the key set with WithIdempotencyKey
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// CallOption overrides the client's configuration for a single call
+//
+// Options passed to a paginated method apply to every page,
+// options passed to Pages.Next only to that page.
+type CallOption func(*callOptions)
+
+// cacheMode is how a call uses the response cache
+type cacheMode int
+
+const (
+	cacheDefault cacheMode = iota
+	cacheBypass
+	cacheRefresh
+)
+
+type callOptions struct {
+	retries     int // -1 keeps the retry policy's own limit
+	retryPolicy RetryPolicy
+	timeout     time.Duration
+	cache       cacheMode
+	priority    Priority
+	header      http.Header
+}
+
+func newCallOptions(opts []CallOption) *callOptions {
+	o := &callOptions{retries: -1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithRetries retries the call at most n times, with the delays of the retry policy
+// Use WithRetries(0) to disable retries.
+func WithRetries(n int) CallOption {
+	return func(o *callOptions) {
+		o.retries = max(n, 0)
+	}
+}
+
+// WithRetryPolicy replaces the client's RetryPolicy for the call
+func WithRetryPolicy(policy RetryPolicy) CallOption {
+	return func(o *callOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithTimeout bounds the call, retries included, to d
+// For paginated and streamed methods it bounds each page.
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// WithCacheBypass makes the call skip the response cache, neither reading nor storing its response
+func WithCacheBypass() CallOption {
+	return func(o *callOptions) {
+		o.cache = cacheBypass
+	}
+}
+
+// WithCacheRefresh makes the call skip cached responses and store its fresh response
+func WithCacheRefresh() CallOption {
+	return func(o *callOptions) {
+		o.cache = cacheRefresh
+	}
+}
+
+// WithPriority sets the priority class of the call in the rate limiter (default: PriorityNormal)
+func WithPriority(priority Priority) CallOption {
+	return func(o *callOptions) {
+		o.priority = priority
+	}
+}
+
+// WithIdempotencyKey tags the call with key in the Idempotency-Key header
+// The same key is sent with every attempt and hedge of the call, so that a gateway can deduplicate them.
+func WithIdempotencyKey(key string) CallOption {
+	return WithHeader(IdempotencyKeyHeader, key)
+}
+
+// WithHeader adds a header to the HTTP requests of the call
+// Calls with extra headers are never deduplicated with other calls.
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Add(key, value)
+	}
+}
+
+// retryPolicyOr returns the retry policy of the call, based on the client's policy
+func (o *callOptions) retryPolicyOr(policy RetryPolicy) RetryPolicy {
+	if o.retryPolicy != nil {
+		policy = o.retryPolicy
+	}
+	switch {
+	case o.retries < 0:
+		return policy
+	case o.retries == 0:
+		return NoRetry()
+	}
+	if backoff, ok := policy.(*ExponentialBackoff); ok {
+		limited := *backoff
+		limited.MaxAttempts = o.retries + 1
+		return &limited
+	}
+	return maxRetries{policy: policy, retries: o.retries}
+}
+
+// withTimeout applies the timeout of the call to ctx
+func (o *callOptions) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, o.timeout, errCallTimeout)
+}
+
+// newHeader returns a copy of the extra headers of the call, never nil
+func (o *callOptions) newHeader() http.Header {
+	if o.header == nil {
+		return make(http.Header)
+	}
+	return o.header.Clone()
+}
+
+// maxRetries caps the number of retries of a policy
+type maxRetries struct {
+	policy  RetryPolicy
+	retries int
+}
+
+func (m maxRetries) Backoff(attempt int, err error) (time.Duration, bool) {
+	if attempt > m.retries {
+		return 0, false
+	}
+	return m.policy.Backoff(attempt, err)
+}
@@ -0,0 +1,173 @@
+package ankr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRetryOptions tests that per-call retry options override the client's retry policy
+func TestRetryOptions(t *testing.T) {
+	var calls atomic.Int32
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}, HTTPClientConfig{
+		RetryPolicy: &ExponentialBackoff{MaxAttempts: 2, InitialDelay: time.Millisecond, Jitter: -1},
+	})
+	req := GetTokenPriceReq{Blockchain: ChainEthereum}
+
+	tests := []struct {
+		name string
+		opts []CallOption
+		want int32
+	}{
+		{"client policy", nil, 2},
+		{"no retries", []CallOption{WithRetries(0)}, 1},
+		{"more retries", []CallOption{WithRetries(4)}, 5},
+		{"policy", []CallOption{WithRetryPolicy(NoRetry())}, 1},
+		{"capped policy", []CallOption{WithRetryPolicy(retryAlways{}), WithRetries(2)}, 3},
+	}
+	for _, tt := range tests {
+		calls.Store(0)
+		if _, err := client.GetTokenPrice(context.Background(), req, tt.opts...); err == nil {
+			t.Fatalf("%s: expected an error", tt.name)
+		}
+		if got := calls.Load(); got != tt.want {
+			t.Errorf("%s: expected %d requests, got %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+// retryAlways retries every error immediately
+type retryAlways struct{}
+
+func (retryAlways) Backoff(int, error) (time.Duration, bool) {
+	return 0, true
+}
+
+// TestTimeoutOption tests that WithTimeout bounds the call
+func TestTimeoutOption(t *testing.T) {
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		writeRPCResult(t, w, 1, GetTokenPriceResp{})
+	}, HTTPClientConfig{})
+
+	start := time.Now()
+	_, err := client.GetTokenPrice(context.Background(), GetTokenPriceReq{Blockchain: ChainEthereum}, WithTimeout(50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the call to give up after its timeout, took %v", elapsed)
+	}
+}
+
+// TestCacheOptions tests that bypassed calls neither read nor store the cache, and refreshed calls store it
+func TestCacheOptions(t *testing.T) {
+	var calls atomic.Int32
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		writeRPCResult(t, w, 1, GetTokenPriceResp{UsdPrice: string(rune('0' + n))})
+	}, HTTPClientConfig{
+		CacheTTLs: map[string]time.Duration{MethodGetTokenPrice: time.Hour},
+	})
+	ctx := context.Background()
+	req := GetTokenPriceReq{Blockchain: ChainEthereum}
+
+	steps := []struct {
+		opts  []CallOption
+		price string
+	}{
+		{nil, "1"},
+		{[]CallOption{WithCacheRefresh()}, "2"},
+		{nil, "2"},
+		{[]CallOption{WithCacheBypass()}, "3"},
+		{nil, "2"},
+	}
+	for i, step := range steps {
+		resp, err := client.GetTokenPrice(ctx, req, step.opts...)
+		if err != nil {
+			t.Fatalf("Step %d: GetTokenPrice failed: %v", i, err)
+		}
+		if resp.UsdPrice != step.price {
+			t.Errorf("Step %d: expected price %s, got %s", i, step.price, resp.UsdPrice)
+		}
+	}
+}
+
+// TestHeaderOptions tests that headers, idempotency keys and priorities reach middlewares and every attempt
+func TestHeaderOptions(t *testing.T) {
+	var calls atomic.Int32
+	var priority atomic.Int32
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(IdempotencyKeyHeader) != "backfill-42" || r.Header.Get("X-Job") != "backfill" {
+			t.Errorf("Missing headers: %v", r.Header)
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeRPCResult(t, w, 1, GetTokenPriceResp{})
+	}, HTTPClientConfig{
+		RetryPolicy: &ExponentialBackoff{InitialDelay: time.Millisecond, Jitter: -1},
+		Middlewares: []Middleware{func(next Handler) Handler {
+			return func(ctx context.Context, req *RPCRequest) (*RPCResponse, error) {
+				priority.Store(int32(req.Priority))
+				return next(ctx, req)
+			}
+		}},
+	})
+
+	_, err := client.GetTokenPrice(context.Background(), GetTokenPriceReq{Blockchain: ChainEthereum},
+		WithIdempotencyKey("backfill-42"),
+		WithHeader("X-Job", "backfill"),
+		WithPriority(PriorityBulk),
+	)
+	if err != nil {
+		t.Fatalf("GetTokenPrice failed: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls.Load())
+	}
+	if Priority(priority.Load()) != PriorityBulk {
+		t.Errorf("Expected bulk priority, got %v", Priority(priority.Load()))
+	}
+}
+
+// TestPageOptions tests that options of Pages.Next apply to that page only, on top of the options of the method
+func TestPageOptions(t *testing.T) {
+	var headers []http.Header
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		next := ""
+		if len(headers) == 1 {
+			next = "page-2"
+		}
+		writeRPCResult(t, w, 1, GetLogsResp{NextPageToken: next})
+	}, HTTPClientConfig{})
+
+	pages := client.GetLogs(GetLogsReq{Blockchain: ChainEthereum}, WithHeader("X-Scan", "logs"))
+	if _, err := pages.Next(context.Background(), WithHeader("X-Page", "first")); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if _, err := pages.Next(context.Background()); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+
+	if len(headers) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(headers))
+	}
+	if headers[0].Get("X-Scan") != "logs" || headers[0].Get("X-Page") != "first" {
+		t.Errorf("Expected both headers on the first page, got %v", headers[0])
+	}
+	if headers[1].Get("X-Scan") != "logs" || headers[1].Get("X-Page") != "" {
+		t.Errorf("Expected only the method's header on the second page, got %v", headers[1])
+	}
+}
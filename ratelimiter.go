@@ -2,6 +2,7 @@ package ankr
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"time"
 )
@@ -478,53 +479,228 @@ import (
 // 	return err
 // }
 
+// DefaultBulkShare is the minimum share of the slots of a SimpleLimiter granted to waiting PriorityBulk calls
+const DefaultBulkShare = 0.1
+
+// Priority is the class of a call when waiting for the rate limiter
+type Priority int
+
+const (
+	// PriorityNormal is the priority of calls without WithPriority
+	PriorityNormal Priority = iota
+
+	// PriorityInteractive is for latency-critical calls, e.g. serving an API
+	// Waiting interactive calls are let through before any other call.
+	PriorityInteractive
+
+	// PriorityBulk is for throughput-oriented calls, e.g. a backfill
+	// Waiting bulk calls are let through last, but still get the limiter's bulk share of the slots.
+	PriorityBulk
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityInteractive:
+		return "interactive"
+	case PriorityBulk:
+		return "bulk"
+	default:
+		return "normal"
+	}
+}
+
+// rank is the order in which waiting calls of priority p are let through
+func (p Priority) rank() int {
+	switch p {
+	case PriorityInteractive:
+		return 0
+	case PriorityBulk:
+		return 2
+	default:
+		return 1
+	}
+}
+
+const bulkRank = 2
+
+// SimpleLimiter lets at most limit calls through per interval, each call holding a slot for interval
+//
+// When every slot is taken, waiting calls are let through by priority class, interactive calls first,
+// then normal and bulk calls, first come first served within a class.
+// Waiting bulk calls still get at least the bulk share of the slots, so that they never starve.
 type SimpleLimiter struct {
-	mu sync.Mutex
-	l  int
-	d  time.Duration
-	c  chan struct{}
+	mu        sync.Mutex
+	l         int
+	d         time.Duration
+	used      int
+	timers    []*time.Timer // release the taken slots, oldest first
+	closed    bool
+	bulkShare float64
+	bulkDebt  float64
+	queues    [bulkRank + 1][]*limiterWaiter
+}
+
+type limiterWaiter struct {
+	ready   chan struct{}
+	granted bool
 }
 
 func NewSimpleLimiter(interval time.Duration, limit int) *SimpleLimiter {
 	return &SimpleLimiter{
-		l: limit,
-		d: interval,
-		c: make(chan struct{}, limit),
+		l:         limit,
+		d:         interval,
+		bulkShare: DefaultBulkShare,
 	}
 }
 
-func (l *SimpleLimiter) Wait(ctx context.Context) {
+// SetBulkShare sets the minimum share of slots granted to waiting bulk calls (default: DefaultBulkShare)
+// 0 lets bulk calls starve behind other calls, 1 and above serves them like normal calls.
+func (l *SimpleLimiter) SetBulkShare(share float64) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.bulkShare = max(share, 0)
+}
+
+// Wait blocks until a slot is free or ctx is done, with PriorityNormal
+func (l *SimpleLimiter) Wait(ctx context.Context) {
+	l.WaitPriority(ctx, PriorityNormal)
+}
+
+// WaitPriority blocks until a slot is granted to a call of priority or ctx is done
+func (l *SimpleLimiter) WaitPriority(ctx context.Context, priority Priority) {
+	l.mu.Lock()
+	if l.closed || l.allow() && l.waiting() == 0 {
+		l.take()
+		l.mu.Unlock()
+		return
+	}
+	w := &limiterWaiter{ready: make(chan struct{})}
+	rank := priority.rank()
+	l.queues[rank] = append(l.queues[rank], w)
+	l.mu.Unlock()
+
 	select {
+	case <-w.ready:
 	case <-ctx.Done():
-		return
-	case l.c <- struct{}{}:
-		go func() {
-			time.Sleep(l.d)
-			<-l.c
-		}()
-		return
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		// A slot granted in the meantime is spent, as if the call had been sent
+		if !w.granted {
+			l.queues[rank] = slices.DeleteFunc(l.queues[rank], func(q *limiterWaiter) bool { return q == w })
+		}
 	}
 }
 
+// TryWait takes a slot if one is free and no call is waiting, without blocking
 func (l *SimpleLimiter) TryWait() bool {
-	if !l.mu.TryLock() {
-		return false
-	}
+	l.mu.Lock()
 	defer l.mu.Unlock()
-
-	if !l.allow() {
+	if !l.closed && (!l.allow() || l.waiting() > 0) {
 		return false
 	}
-	l.c <- struct{}{}
-	go func() {
-		time.Sleep(l.d)
-		<-l.c
-	}()
+	l.take()
 	return true
 }
 
+// Close stops the timers of the taken slots and lets every waiting and future call through
+func (l *SimpleLimiter) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	for _, timer := range l.timers {
+		timer.Stop()
+	}
+	l.timers, l.used = nil, 0
+	for rank, queue := range l.queues {
+		for _, w := range queue {
+			w.granted = true
+			close(w.ready)
+		}
+		l.queues[rank] = nil
+	}
+}
+
+// inUse returns the number of slots taken
+func (l *SimpleLimiter) inUse() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.used
+}
+
 func (l *SimpleLimiter) allow() bool {
-	return len(l.c) < l.l
+	return l.used < l.l
+}
+
+// waiting returns the number of waiting calls, l.mu must be held
+func (l *SimpleLimiter) waiting() int {
+	n := 0
+	for _, queue := range l.queues {
+		n += len(queue)
+	}
+	return n
+}
+
+// take takes a slot for the interval, l.mu must be held
+func (l *SimpleLimiter) take() {
+	if l.closed {
+		return
+	}
+	l.used++
+	l.timers = append(l.timers, time.AfterFunc(l.d, l.release))
+}
+
+// release frees a slot and grants the free slots to waiting calls
+func (l *SimpleLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	l.timers[0] = nil
+	l.timers = l.timers[1:]
+	l.used--
+	for l.allow() {
+		w := l.dequeue()
+		if w == nil {
+			return
+		}
+		l.take()
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// dequeue removes and returns the next waiting call to let through, nil if none is waiting
+//
+// Every slot granted to another class while bulk calls are waiting
+// owes them a fraction of a slot, repaid as soon as a whole slot is owed.
+func (l *SimpleLimiter) dequeue() *limiterWaiter {
+	bulkWaiting := len(l.queues[bulkRank]) > 0
+	if !bulkWaiting {
+		l.bulkDebt = 0
+	}
+	if bulkWaiting && (l.bulkShare >= 1 || l.bulkDebt >= 1) {
+		l.bulkDebt = max(l.bulkDebt-1, 0)
+		return l.pop(bulkRank)
+	}
+	for rank := range bulkRank {
+		if len(l.queues[rank]) == 0 {
+			continue
+		}
+		if bulkWaiting && l.bulkShare < 1 {
+			l.bulkDebt += l.bulkShare / (1 - l.bulkShare)
+		}
+		return l.pop(rank)
+	}
+	if bulkWaiting {
+		return l.pop(bulkRank)
+	}
+	return nil
+}
+
+func (l *SimpleLimiter) pop(rank int) *limiterWaiter {
+	w := l.queues[rank][0]
+	l.queues[rank][0] = nil
+	l.queues[rank] = l.queues[rank][1:]
+	return w
 }
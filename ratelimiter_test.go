@@ -0,0 +1,135 @@
+package ankr
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// queueCalls makes each call of priorities wait for l in order, and returns the order they are let through
+func queueCalls(t *testing.T, l *SimpleLimiter, priorities []Priority) []Priority {
+	t.Helper()
+	var mu sync.Mutex
+	var order []Priority
+	var wg sync.WaitGroup
+	for i, priority := range priorities {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.WaitPriority(context.Background(), priority)
+			mu.Lock()
+			order = append(order, priority)
+			mu.Unlock()
+		}()
+		waitQueued(t, l, i+1)
+	}
+	wg.Wait()
+	return order
+}
+
+// waitQueued waits until n calls are waiting for l
+func waitQueued(t *testing.T, l *SimpleLimiter, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		l.mu.Lock()
+		waiting := l.waiting()
+		l.mu.Unlock()
+		if waiting >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d waiting calls, got %d", n, waiting)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestLimiterPriority tests that waiting calls are let through by priority class, in order within a class
+func TestLimiterPriority(t *testing.T) {
+	l := NewSimpleLimiter(30*time.Millisecond, 1)
+	l.SetBulkShare(0)
+	l.Wait(context.Background())
+
+	order := queueCalls(t, l, []Priority{PriorityBulk, PriorityNormal, PriorityBulk, PriorityInteractive, PriorityNormal})
+	want := []Priority{PriorityInteractive, PriorityNormal, PriorityNormal, PriorityBulk, PriorityBulk}
+	if !slices.Equal(order, want) {
+		t.Errorf("Expected %v, got %v", want, order)
+	}
+}
+
+// TestLimiterBulkShare tests that waiting bulk calls get their share of the slots
+func TestLimiterBulkShare(t *testing.T) {
+	l := NewSimpleLimiter(30*time.Millisecond, 1)
+	l.SetBulkShare(0.25)
+	l.Wait(context.Background())
+
+	priorities := make([]Priority, 0, 12)
+	for range 4 {
+		priorities = append(priorities, PriorityBulk)
+	}
+	for range 8 {
+		priorities = append(priorities, PriorityInteractive)
+	}
+	order := queueCalls(t, l, priorities)
+
+	bulk := 0
+	for _, priority := range order[:8] {
+		if priority == PriorityBulk {
+			bulk++
+		}
+	}
+	if bulk != 2 {
+		t.Errorf("Expected 2 of the first 8 slots to go to bulk calls, got %v", order)
+	}
+}
+
+// TestLimiterCancel tests that cancelled calls leave the queue and that TryWait doesn't jump it
+func TestLimiterCancel(t *testing.T) {
+	l := NewSimpleLimiter(50*time.Millisecond, 1)
+	if !l.TryWait() {
+		t.Fatal("Expected a free slot")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		l.WaitPriority(ctx, PriorityInteractive)
+		close(done)
+	}()
+	waitQueued(t, l, 1)
+	cancel()
+	<-done
+	waitQueued(t, l, 0)
+
+	go l.Wait(context.Background())
+	waitQueued(t, l, 1)
+	time.Sleep(60 * time.Millisecond)
+	if l.TryWait() {
+		t.Error("Expected TryWait to fail while the slot is taken by the waiting call")
+	}
+}
+
+// TestLimiterClose tests that Close lets waiting and future calls through
+func TestLimiterClose(t *testing.T) {
+	l := NewSimpleLimiter(time.Hour, 1)
+	l.Wait(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		l.WaitPriority(context.Background(), PriorityBulk)
+		close(done)
+	}()
+	waitQueued(t, l, 1)
+	l.Close()
+	<-done
+
+	if !l.TryWait() {
+		t.Error("Expected a closed limiter to let calls through")
+	}
+	if l.inUse() != 0 {
+		t.Errorf("Expected no slot in use, got %d", l.inUse())
+	}
+}
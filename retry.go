@@ -0,0 +1,192 @@
+package ankr
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides whether and when a failed call is retried
+type RetryPolicy interface {
+	// Backoff is called after every failed attempt (starting at 1) with its error
+	// It returns how long to wait before the next attempt, or false to give up
+	Backoff(attempt int, err error) (delay time.Duration, retry bool)
+}
+
+// ExponentialBackoff retries retryable errors with exponentially growing, jittered delays
+//
+// A Retry-After header of a failed response takes precedence over the computed delay,
+// the call gives up if it is longer than MaxDelay.
+// Zero fields use the defaults of DefaultRetryPolicy.
+type ExponentialBackoff struct {
+	// MaxAttempts is the maximum number of attempts including the first one (default: 3)
+	MaxAttempts int
+
+	// InitialDelay is the delay before the first retry (default: 500ms)
+	InitialDelay time.Duration
+
+	// MaxDelay caps the computed delay and Retry-After delays (default: 30s)
+	MaxDelay time.Duration
+
+	// Multiplier is the growth factor of the delay between retries (default: 2)
+	Multiplier float64
+
+	// Jitter randomizes each delay by up to this fraction in both directions (default: 0.2)
+	// Use a negative value to disable jitter
+	Jitter float64
+
+	// Retryable classifies errors as retryable (default: IsRetryable)
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy returns the policy used when HTTPClientConfig.RetryPolicy is nil
+func DefaultRetryPolicy() *ExponentialBackoff {
+	return &ExponentialBackoff{
+		MaxAttempts:  3,
+		InitialDelay: 500 * time.Millisecond,
+		MaxDelay:     30 * time.Second,
+		Multiplier:   2,
+		Jitter:       0.2,
+		Retryable:    IsRetryable,
+	}
+}
+
+// Backoff implements RetryPolicy
+func (b *ExponentialBackoff) Backoff(attempt int, err error) (time.Duration, bool) {
+	def := DefaultRetryPolicy()
+	maxAttempts := cmp.Or(b.MaxAttempts, def.MaxAttempts)
+	initialDelay := cmp.Or(b.InitialDelay, def.InitialDelay)
+	maxDelay := cmp.Or(b.MaxDelay, def.MaxDelay)
+	multiplier := cmp.Or(b.Multiplier, def.Multiplier)
+	jitter := cmp.Or(b.Jitter, def.Jitter)
+	retryable := b.Retryable
+	if retryable == nil {
+		retryable = def.Retryable
+	}
+
+	if attempt >= maxAttempts || !retryable(err) {
+		return 0, false
+	}
+
+	if retryAfter, ok := RetryAfter(err); ok {
+		// Retrying sooner than asked would be rejected again
+		return retryAfter, retryAfter <= maxDelay
+	}
+
+	delay := float64(initialDelay) * math.Pow(multiplier, float64(attempt-1))
+	delay = min(delay, float64(maxDelay))
+	if jitter > 0 {
+		delay += delay * jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay), true
+}
+
+// NoRetry returns a policy that never retries
+func NoRetry() RetryPolicy {
+	return noRetry{}
+}
+
+type noRetry struct{}
+
+func (noRetry) Backoff(int, error) (time.Duration, bool) {
+	return 0, false
+}
+
+// IsRetryable reports whether a call that failed with err may succeed when retried
+//
+// Cancellation, a closed client, invalid requests, quota errors, exceeded budgets, responses over MaxResponseSize, open circuits,
+// 4xx responses other than 408, 425 and 429,
+// and JSON-RPC errors about malformed requests or invalid params are not retryable.
+// Timeouts, network errors, 5xx responses, rate limits and undecodable responses are.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrClientClosed) || errors.Is(err, ErrInvalidRequest) ||
+		errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrBudgetExceeded) {
+		return false
+	}
+
+	// The same response would be too large again
+	var sizeErr *ResponseTooLargeError
+	if errors.As(err, &sizeErr) {
+		return false
+	}
+
+	// Open circuits are meant to fail fast
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch code := statusErr.StatusCode; {
+		case code == http.StatusRequestTimeout, code == http.StatusTooEarly, code == http.StatusTooManyRequests:
+			return true
+		case code >= 400 && code < 500:
+			return false
+		}
+		return true
+	}
+
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		if errors.Is(rpcErr, ErrRateLimited) {
+			return true
+		}
+		switch rpcErr.Code {
+		case -32700, // parse error
+			-32600, // invalid request
+			-32601, // method not found
+			-32602: // invalid params
+			return false
+		}
+		return true
+	}
+
+	return true
+}
+
+// RetryAfter returns the delay requested by the Retry-After header of a failed response
+func RetryAfter(err error) (time.Duration, bool) {
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		return 0, false
+	}
+	return statusErr.RetryAfter()
+}
+
+// RetryAfter parses the Retry-After header of the response, in seconds or as an HTTP date
+func (e *HTTPStatusError) RetryAfter() (time.Duration, bool) {
+	value := e.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// sleepCtx waits for d or until ctx is done
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
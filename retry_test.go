@@ -0,0 +1,132 @@
+package ankr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestIsRetryable tests the default retryability classification
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"canceled", context.Canceled, false},
+		{"timeout", fmt.Errorf("request failed: %w", context.DeadlineExceeded), true},
+		{"network", errors.New("connection reset by peer"), true},
+		{"429", &HTTPStatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"429 quota", &HTTPStatusError{StatusCode: http.StatusTooManyRequests, Body: []byte("quota exceeded")}, false},
+		{"400", &HTTPStatusError{StatusCode: http.StatusBadRequest}, false},
+		{"404", &HTTPStatusError{StatusCode: http.StatusNotFound}, false},
+		{"502", &HTTPStatusError{StatusCode: http.StatusBadGateway}, true},
+		{"invalid params", &RPCError{RPCRespError: RPCRespError{Code: -32602, Message: "invalid params"}}, false},
+		{"method not found", &RPCError{RPCRespError: RPCRespError{Code: -32601}}, false},
+		{"internal", &RPCError{RPCRespError: RPCRespError{Code: -32603}}, true},
+		{"rpc rate limit", &RPCError{RPCRespError: RPCRespError{Code: -32602, Message: "rate limit exceeded"}}, true},
+		{"decode", &DecodeError{Err: errors.New("unexpected EOF")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExponentialBackoff tests delay growth, capping and Retry-After precedence
+func TestExponentialBackoff(t *testing.T) {
+	policy := &ExponentialBackoff{
+		MaxAttempts:  5,
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     300 * time.Millisecond,
+		Jitter:       -1,
+	}
+	retryable := &HTTPStatusError{StatusCode: http.StatusBadGateway}
+
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond} {
+		delay, retry := policy.Backoff(attempt+1, retryable)
+		if !retry || delay != want {
+			t.Errorf("Attempt %d: got (%v, %v), want (%v, true)", attempt+1, delay, retry, want)
+		}
+	}
+	if _, retry := policy.Backoff(5, retryable); retry {
+		t.Error("Expected no retry after MaxAttempts")
+	}
+
+	withRetryAfter := &HTTPStatusError{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"7"}}}
+	if _, retry := policy.Backoff(1, withRetryAfter); retry {
+		t.Error("Expected no retry when Retry-After is longer than MaxDelay")
+	}
+	policy.MaxDelay = 10 * time.Second
+	if delay, retry := policy.Backoff(1, withRetryAfter); !retry || delay != 7*time.Second {
+		t.Errorf("Expected Retry-After delay of 7s, got (%v, %v)", delay, retry)
+	}
+}
+
+// TestPostWithRetries tests that transient errors are retried and permanent ones are not
+func TestPostWithRetries(t *testing.T) {
+	policy := &ExponentialBackoff{InitialDelay: time.Millisecond, Jitter: -1}
+
+	t.Run("transient", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			writeRPCResult(t, w, 1, GetCurrenciesResp{Currencies: []Currency{{Symbol: "ETH"}}})
+		}, HTTPClientConfig{RetryPolicy: policy})
+
+		if _, err := client.GetCurrencies(context.Background(), GetCurrenciesReq{Blockchain: ChainEthereum}); err != nil {
+			t.Fatalf("GetCurrencies failed: %v", err)
+		}
+		if calls.Load() != 2 {
+			t.Errorf("Expected 2 calls, got %d", calls.Load())
+		}
+	})
+
+	t.Run("permanent", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid params"}}`))
+		}, HTTPClientConfig{RetryPolicy: policy})
+
+		_, err := client.GetCurrencies(context.Background(), GetCurrenciesReq{Blockchain: ChainEthereum})
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			t.Fatalf("Expected *RPCError, got %v", err)
+		}
+		if calls.Load() != 1 {
+			t.Errorf("Expected 1 call, got %d", calls.Load())
+		}
+	})
+
+	t.Run("cancelled during backoff", func(t *testing.T) {
+		client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}, HTTPClientConfig{RetryPolicy: &ExponentialBackoff{InitialDelay: time.Minute}})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := client.GetCurrencies(ctx, GetCurrenciesReq{Blockchain: ChainEthereum})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected deadline exceeded, got %v", err)
+		}
+		var statusErr *HTTPStatusError
+		if !errors.As(err, &statusErr) {
+			t.Errorf("Expected the last error to be kept, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected backoff to stop on cancellation, took %v", elapsed)
+		}
+	})
+}
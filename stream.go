@@ -0,0 +1,322 @@
+package ankr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// streamItems returns an iterator over the items of every page of a paginated method
+//
+// Pages are decoded as a stream: items are handed out one at a time as they are parsed,
+// so a page is never held in memory as a whole. Pages go through the rate limiter,
+// the key pool and endpoint failover, but not through middlewares, the cache or deduplication.
+// Opening a page is retried per the retry policy of the call; any other error ends the iteration.
+func streamItems[Req PageRequest, Item any](ctx context.Context, client *HTTPClient, method string, req Req, field string, opts []CallOption) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		var zero Item
+		done, err := client.lifecycle.enter()
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer done()
+
+		req, err := ApplyDefaults(req)
+		if err != nil {
+			yield(zero, fmt.Errorf("failed to apply defaults: %w", err))
+			return
+		}
+		if err := validate(req); err != nil {
+			yield(zero, err)
+			return
+		}
+
+		o := newCallOptions(opts)
+		for {
+			next, stopped, err := streamPage(ctx, client, method, req, field, o, yield)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if stopped || next == "" {
+				return
+			}
+			req.SetPageToken(next)
+		}
+	}
+}
+
+// streamPage opens a page of method and yields its items, within the timeout of the call
+func streamPage[Item any](ctx context.Context, client *HTTPClient, method string, params any, field string, o *callOptions, yield func(Item, error) bool) (next string, stopped bool, err error) {
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+	body, err := client.openStreamWithRetries(ctx, method, params, o)
+	if err != nil {
+		return "", false, err
+	}
+	defer body.Close()
+	return decodeItemStream(body, method, field, yield)
+}
+
+// openStreamWithRetries calls openStream until it succeeds or the retry policy of the call gives up
+func (c *HTTPClient) openStreamWithRetries(ctx context.Context, method string, params any, o *callOptions) (io.ReadCloser, error) {
+	retryPolicy := o.retryPolicyOr(c.retryPolicy)
+	for attempt := 1; ; attempt++ {
+		body, err := c.openStream(ctx, method, params, o)
+		if err == nil {
+			return body, nil
+		}
+		delay, retry := retryPolicy.Backoff(attempt, err)
+		if ctx.Err() != nil || !retry {
+			return nil, fmt.Errorf("ankr: %s failed after %d attempt(s): %w", method, attempt, err)
+		}
+		c.metrics.ObserveRetry(method, attempt)
+		c.logger.WarnContext(ctx, "ankr: failed to open stream, retrying...", "method", method, "attempt", attempt, "delay", delay, "error", err)
+		if ctxErr := sleepCtx(ctx, delay); ctxErr != nil {
+			return nil, fmt.Errorf("ankr: %s aborted after %d attempt(s): %w, last error: %w", method, attempt, ctxErr, err)
+		}
+	}
+}
+
+// openStream sends a JSON-RPC call and returns the response body without reading it
+func (c *HTTPClient) openStream(ctx context.Context, method string, params any, o *callOptions) (io.ReadCloser, error) {
+	// Open circuits and budgets are checked first, so that rejected pages fail fast
+	// without being charged nor taking a token of the rate limiter
+	if err := c.breaker.rejectsAll(method, c.endpoints.endpoints); err != nil {
+		return nil, err
+	}
+	key, _ := c.keys.pick(nil)
+	settle, err := c.chargeCredits(ctx, key, method)
+	if err != nil {
+		return nil, err
+	}
+	c.waitLimiter(ctx, key.limiter, method, o.priority)
+
+	request := RPCReqBody{
+		ID:      c.nextID.Add(1),
+		JSONRPC: JSONRPC,
+		Method:  method,
+		Params:  params,
+	}
+
+	key.requests.Add(1)
+	var resp *http.Response
+	err = c.withFailover(ctx, method, key, func(ctx context.Context, ep *endpoint) (err error) {
+		start := time.Now()
+		resp, err = c.do(ctx, ep.url+key.key, request, o.header)
+		if err == nil && resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			err = &HTTPStatusError{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+		}
+
+		stats := RequestStats{Method: method, Duration: time.Since(start), Err: err}
+		var statusErr *HTTPStatusError
+		switch {
+		case err == nil:
+			// The request is observed once the stream is closed, with the bytes read
+			stats.StatusCode = resp.StatusCode
+			resp.Body = &countingBody{ReadCloser: resp.Body, closed: func(n int) {
+				stats.BytesReceived = n
+				c.metrics.ObserveRequest(stats)
+			}}
+			return nil
+		case errors.As(err, &statusErr):
+			stats.StatusCode = statusErr.StatusCode
+			stats.BytesReceived = len(statusErr.Body)
+		}
+		c.metrics.ObserveRequest(stats)
+		return err
+	})
+	c.keys.observe(key, err)
+	if err == nil {
+		settle(resp.StatusCode)
+	} else {
+		settle(0)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// countingBody counts the bytes read from a streamed body, and reports them once it is closed
+type countingBody struct {
+	io.ReadCloser
+	n      int
+	closed func(n int)
+	once   sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += n
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.closed(b.n) })
+	return err
+}
+
+// decodeItemStream decodes a JSON-RPC response from r and yields the items of the array
+// named field of its result one at a time
+//
+// It returns the next page token of the result, and whether yield asked to stop.
+func decodeItemStream[Item any](r io.Reader, method, field string, yield func(Item, error) bool) (next string, stopped bool, err error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return "", false, streamError(method, err)
+	}
+	for dec.More() {
+		name, err := dec.Token()
+		if err != nil {
+			return "", false, streamError(method, err)
+		}
+		switch name {
+		case "result":
+			next, stopped, err = decodeResultStream(dec, field, yield)
+			if err != nil {
+				return "", false, streamError(method, err)
+			}
+			if stopped {
+				return next, true, nil
+			}
+		case "error":
+			var respErr *RPCRespError
+			if err := dec.Decode(&respErr); err != nil {
+				return "", false, streamError(method, err)
+			}
+			if respErr != nil {
+				return "", false, newRPCError(method, respErr)
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return "", false, streamError(method, err)
+			}
+		}
+	}
+	return next, false, nil
+}
+
+// decodeResultStream decodes the result object of a response, yielding the items of the array named field
+func decodeResultStream[Item any](dec *json.Decoder, field string, yield func(Item, error) bool) (next string, stopped bool, err error) {
+	tok, err := dec.Token()
+	if err != nil || tok == nil {
+		// A null result has no items
+		return "", false, err
+	}
+	if tok != json.Delim('{') {
+		return "", false, fmt.Errorf("expected result object, got %v", tok)
+	}
+	for dec.More() {
+		name, err := dec.Token()
+		if err != nil {
+			return "", false, err
+		}
+		switch name {
+		case field:
+			if stopped, err = decodeArrayStream(dec, yield); err != nil || stopped {
+				return "", stopped, err
+			}
+		case "nextPageToken":
+			if err := dec.Decode(&next); err != nil {
+				return "", false, err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return "", false, err
+			}
+		}
+	}
+	_, err = dec.Token() // closing brace
+	return next, false, err
+}
+
+// decodeArrayStream yields the elements of a JSON array one at a time, and reports whether yield asked to stop
+func decodeArrayStream[Item any](dec *json.Decoder, yield func(Item, error) bool) (stopped bool, err error) {
+	tok, err := dec.Token()
+	if err != nil || tok == nil {
+		return false, err
+	}
+	if tok != json.Delim('[') {
+		return false, fmt.Errorf("expected array, got %v", tok)
+	}
+	for dec.More() {
+		var item Item
+		if err := dec.Decode(&item); err != nil {
+			return false, err
+		}
+		if !yield(item, nil) {
+			return true, nil
+		}
+	}
+	_, err = dec.Token() // closing bracket
+	return false, err
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+// streamError reports a failure to read a streamed response
+// as a *ResponseTooLargeError or a *DecodeError without body
+func streamError(method string, err error) error {
+	var sizeErr *ResponseTooLargeError
+	if err = responseSizeError(err); errors.As(err, &sizeErr) {
+		return err
+	}
+	return &DecodeError{Method: method, Err: err}
+}
+
+// ============================================================================
+// Streaming Methods
+// ============================================================================
+
+// StreamLogs returns an iterator over the logs of every page, decoded one at a time
+//
+// Unlike GetLogs, pages are never held in memory as a whole, which suits large page sizes.
+// Streamed calls skip middlewares, the response cache and deduplication.
+// The iteration ends at the first error.
+func (c *HTTPClient) StreamLogs(ctx context.Context, req GetLogsReq, opts ...CallOption) iter.Seq2[Log, error] {
+	return streamItems[*GetLogsReq, Log](ctx, c, MethodGetLogs, &req, "logs", opts)
+}
+
+// StreamTokenTransfers returns an iterator over the token transfers of every page, decoded one at a time
+//
+// See StreamLogs for how streamed calls differ from paginated ones.
+func (c *HTTPClient) StreamTokenTransfers(ctx context.Context, req GetTokenTransfersReq, opts ...CallOption) iter.Seq2[TokenTransfer, error] {
+	return streamItems[*GetTokenTransfersReq, TokenTransfer](ctx, c, MethodGetTokenTransfers, &req, "transfers", opts)
+}
+
+// StreamNFTTransfers returns an iterator over the NFT transfers of every page, decoded one at a time
+//
+// See StreamLogs for how streamed calls differ from paginated ones.
+func (c *HTTPClient) StreamNFTTransfers(ctx context.Context, req GetNFTTransfersReq, opts ...CallOption) iter.Seq2[NFTTransfer, error] {
+	return streamItems[*GetNFTTransfersReq, NFTTransfer](ctx, c, MethodGetNFTTransfers, &req, "transfers", opts)
+}
+
+// StreamTxsByAddress returns an iterator over the transactions of every page, decoded one at a time
+//
+// See StreamLogs for how streamed calls differ from paginated ones.
+func (c *HTTPClient) StreamTxsByAddress(ctx context.Context, req GetTxsByAddressReq, opts ...CallOption) iter.Seq2[Tx, error] {
+	return streamItems[*GetTxsByAddressReq, Tx](ctx, c, MethodGetTxsByAddress, &req, "transactions", opts)
+}
@@ -0,0 +1,129 @@
+package ankr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestStreamLogs tests that logs of every page are handed out one at a time
+func TestStreamLogs(t *testing.T) {
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params GetLogsReq `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		resp := GetLogsResp{Logs: []Log{{Address: "0x3"}}}
+		if req.Params.PageToken == "" {
+			resp = GetLogsResp{Logs: []Log{{Address: "0x1"}, {Address: "0x2"}}, NextPageToken: "page-2"}
+		}
+		writeRPCResult(t, w, 1, resp)
+	}, HTTPClientConfig{})
+
+	var addresses []string
+	for log, err := range client.StreamLogs(context.Background(), GetLogsReq{Blockchain: ChainEthereum}) {
+		if err != nil {
+			t.Fatalf("StreamLogs failed: %v", err)
+		}
+		addresses = append(addresses, log.Address)
+	}
+	if strings.Join(addresses, ",") != "0x1,0x2,0x3" {
+		t.Errorf("Expected the logs of both pages in order, got %v", addresses)
+	}
+
+	addresses = nil
+	for log := range client.StreamLogs(context.Background(), GetLogsReq{Blockchain: ChainEthereum}) {
+		addresses = append(addresses, log.Address)
+		break
+	}
+	if len(addresses) != 1 {
+		t.Errorf("Expected the iteration to stop after 1 log, got %v", addresses)
+	}
+}
+
+// TestStreamRPCError tests that a JSON-RPC error ends the iteration
+func TestStreamRPCError(t *testing.T) {
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid params"}}`))
+	}, HTTPClientConfig{})
+
+	var rpcErr *RPCError
+	for _, err := range client.StreamTokenTransfers(context.Background(), GetTokenTransfersReq{}) {
+		if !errors.As(err, &rpcErr) || rpcErr.Code != -32602 {
+			t.Errorf("Expected the RPC error, got %v", err)
+		}
+	}
+	if rpcErr == nil {
+		t.Error("Expected an error to be yielded")
+	}
+}
+
+// TestMaxResponseSize tests that responses over the limit fail with a *ResponseTooLargeError
+func TestMaxResponseSize(t *testing.T) {
+	logs := make([]Log, 100)
+	for i := range logs {
+		logs[i].Address = "0x0000000000000000000000000000000000000000"
+	}
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// Flushing drops the Content-Length header, so the limit is hit while reading
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		writeRPCResult(t, w, 1, GetLogsResp{Logs: logs})
+	}, HTTPClientConfig{MaxResponseSize: 1024})
+
+	var sizeErr *ResponseTooLargeError
+	_, err := client.GetLogs(GetLogsReq{}).Next(context.Background())
+	if !errors.As(err, &sizeErr) || sizeErr.Limit != 1024 {
+		t.Fatalf("Expected a *ResponseTooLargeError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "after 1 attempt(s)") {
+		t.Errorf("Expected the error not to be retried, got %v", err)
+	}
+
+	var streamed int
+	for _, err := range client.StreamLogs(context.Background(), GetLogsReq{}) {
+		if err != nil {
+			if !errors.As(err, &sizeErr) {
+				t.Errorf("Expected a *ResponseTooLargeError, got %v", err)
+			}
+			break
+		}
+		streamed++
+	}
+	if streamed == 0 || streamed == len(logs) {
+		t.Errorf("Expected some logs to be streamed before the limit, got %d", streamed)
+	}
+}
+
+// TestStreamMetrics tests that streamed pages are observed with the bytes read once closed
+func TestStreamMetrics(t *testing.T) {
+	body := `{"jsonrpc":"2.0","id":1,"result":{"logs":[{"address":"0x1"},{"address":"0x2"}]}}`
+	metrics := NewPrometheusMetrics()
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}, HTTPClientConfig{Metrics: metrics})
+
+	for _, err := range client.StreamLogs(context.Background(), GetLogsReq{Blockchain: ChainEthereum}) {
+		if err != nil {
+			t.Fatalf("StreamLogs failed: %v", err)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`ankr_requests_total{method="ankr_getLogs",status="200"} 1`,
+		fmt.Sprintf(`ankr_response_bytes_total{method="ankr_getLogs"} %d`, len(body)),
+	} {
+		if !strings.Contains(recorder.Body.String(), want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, recorder.Body.String())
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package ankr
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// Tracer starts spans around client calls
+//
+// It follows OpenTelemetry semantics without depending on it: Start returns a context
+// carrying the new span, so spans started with it nest under it.
+// An adapter to an OpenTelemetry trace.Tracer only needs to convert the attributes.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a single traced operation
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key-value pair attached to a span
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Span names and attribute keys used by the client
+const (
+	SpanPost      = "ankr.post"
+	SpanPagesNext = "ankr.pages.next"
+	SpanBatch     = "ankr.batch"
+
+	AttrRPCMethod    = "rpc.method"
+	AttrChain        = "ankr.chain"
+	AttrAttempt      = "ankr.attempt"
+	AttrPage         = "ankr.page"
+	AttrHasNext      = "ankr.has_next"
+	AttrBatchSize    = "ankr.batch_size"
+	AttrStatusCode   = "http.response.status_code"
+	AttrResponseSize = "ankr.response_size"
+)
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+type postSpanKey struct{}
+
+// startPostSpan starts the span of a single post attempt
+// The span is kept in the context so that roundTrip can attach response attributes
+func (c *HTTPClient) startPostSpan(ctx context.Context, method string, params any, attempt int) (context.Context, Span) {
+	ctx, span := c.tracer.Start(ctx, SpanPost,
+		Attribute{AttrRPCMethod, method},
+		Attribute{AttrChain, chainOf(params)},
+		Attribute{AttrAttempt, attempt},
+	)
+	return context.WithValue(ctx, postSpanKey{}, span), span
+}
+
+// postSpan returns the span of the current post attempt
+func postSpan(ctx context.Context) Span {
+	if span, ok := ctx.Value(postSpanKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// chainOf returns the Blockchain field of a request as a comma separated list
+func chainOf(params any) string {
+	v := reflect.ValueOf(params)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	field := v.FieldByName("Blockchain")
+	switch field.Kind() {
+	case reflect.String:
+		return field.String()
+	case reflect.Slice:
+		chains := make([]string, field.Len())
+		for i := range chains {
+			chains[i] = field.Index(i).String()
+		}
+		return strings.Join(chains, ",")
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package ankr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// recordedSpan is a span kept by recordingTracer
+type recordedSpan struct {
+	name   string
+	parent *recordedSpan
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...Attribute) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) { s.err = err }
+func (s *recordedSpan) End()                  { s.ended = true }
+
+type recordingSpanKey struct{}
+
+// recordingTracer records every span with its parent
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	parent, _ := ctx.Value(recordingSpanKey{}).(*recordedSpan)
+	span := &recordedSpan{name: name, parent: parent, attrs: make(map[string]any)}
+	span.SetAttributes(attrs...)
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return context.WithValue(ctx, recordingSpanKey{}, span), span
+}
+
+// TestTracing tests that page and post spans are nested and carry their attributes
+func TestTracing(t *testing.T) {
+	tracer := &recordingTracer{}
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params GetLogsReq `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		resp := GetLogsResp{Logs: []Log{{Address: "0x1"}}}
+		if req.Params.PageToken == "" {
+			resp.NextPageToken = "page-2"
+		}
+		writeRPCResult(t, w, 1, resp)
+	}, HTTPClientConfig{Tracer: tracer})
+
+	pages := client.GetLogs(GetLogsReq{Blockchain: ChainEthereum, FromBlock: "latest"})
+	ctx, root := tracer.Start(context.Background(), "root")
+	for pages.HasNext() {
+		if _, err := pages.Next(ctx); err != nil {
+			t.Fatalf("Failed to get next logs page: %v", err)
+		}
+	}
+	root.End()
+
+	var pageSpans, postSpans []*recordedSpan
+	for _, span := range tracer.spans {
+		if !span.ended {
+			t.Errorf("Span %s was not ended", span.name)
+		}
+		switch span.name {
+		case SpanPagesNext:
+			pageSpans = append(pageSpans, span)
+		case SpanPost:
+			postSpans = append(postSpans, span)
+		}
+	}
+
+	if len(pageSpans) != 2 || len(postSpans) != 2 {
+		t.Fatalf("Expected 2 page and 2 post spans, got %d and %d", len(pageSpans), len(postSpans))
+	}
+	for i, span := range pageSpans {
+		if span.parent == nil || span.parent.name != "root" {
+			t.Errorf("Expected page span %d to be nested under the caller's span", i)
+		}
+		if span.attrs[AttrPage] != i+1 || span.attrs[AttrChain] != "eth" || span.attrs[AttrRPCMethod] != MethodGetLogs {
+			t.Errorf("Unexpected page span attributes: %v", span.attrs)
+		}
+		if post := postSpans[i]; post.parent != span {
+			t.Errorf("Expected post span %d to be nested under its page span", i)
+		}
+	}
+	if attrs := postSpans[0].attrs; attrs[AttrAttempt] != 1 || attrs[AttrStatusCode] != http.StatusOK || attrs[AttrResponseSize].(int) == 0 {
+		t.Errorf("Unexpected post span attributes: %v", attrs)
+	}
+}
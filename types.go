@@ -47,6 +47,26 @@ const (
 
 const JSONRPC = "2.0"
 
+// JSON-RPC method names of the Ankr Advanced API
+const (
+	MethodGetNFTsByOwner       = "ankr_getNFTsByOwner"
+	MethodGetNFTMetadata       = "ankr_getNFTMetadata"
+	MethodGetNFTHolders        = "ankr_getNFTHolders"
+	MethodGetNFTTransfers      = "ankr_getNftTransfers"
+	MethodGetBlockchainStats   = "ankr_getBlockchainStats"
+	MethodGetBlocks            = "ankr_getBlocks"
+	MethodGetLogs              = "ankr_getLogs"
+	MethodGetTxsByHash         = "ankr_getTransactionsByHash"
+	MethodGetTxsByAddress      = "ankr_getTransactionsByAddress"
+	MethodGetInteractions      = "ankr_getInteractions"
+	MethodGetAccountBalance    = "ankr_getAccountBalance"
+	MethodGetCurrencies        = "ankr_getCurrencies"
+	MethodGetTokenPrice        = "ankr_getTokenPrice"
+	MethodGetTokenHolders      = "ankr_getTokenHolders"
+	MethodGetTokenHoldersCount = "ankr_getTokenHoldersCount"
+	MethodGetTokenTransfers    = "ankr_getTokenTransfers"
+)
+
 type RPCReqBody struct {
 	ID      int64  `json:"id" bson:"id"`
 	JSONRPC string `json:"jsonrpc" bson:"jsonrpc"`
@@ -91,8 +111,8 @@ type GetNFTsByOwnerReq struct {
 	Filter map[string][]string `json:"filter,omitempty" bson:"filter,omitempty"`
 }
 
-// setPageToken sets the page token for pagination
-func (r *GetNFTsByOwnerReq) setPageToken(token string) {
+// SetPageToken sets the page token for pagination
+func (r *GetNFTsByOwnerReq) SetPageToken(token string) {
 	r.PageToken = token
 }
 
@@ -144,8 +164,8 @@ type GetNFTsByOwnerResp struct {
 	NextPageToken string `json:"nextPageToken" bson:"nextPageToken"`
 }
 
-// getNextPageToken returns the next page token for pagination
-func (r *GetNFTsByOwnerResp) getNextPageToken() string {
+// GetNextPageToken returns the next page token for pagination
+func (r *GetNFTsByOwnerResp) GetNextPageToken() string {
 	return r.NextPageToken
 }
 
@@ -231,8 +251,8 @@ type GetNFTHoldersReq struct {
 	PageToken string `json:"pageToken,omitempty" bson:"pageToken,omitempty"`
 }
 
-// setPageToken sets the page token for pagination
-func (r *GetNFTHoldersReq) setPageToken(token string) {
+// SetPageToken sets the page token for pagination
+func (r *GetNFTHoldersReq) SetPageToken(token string) {
 	r.PageToken = token
 }
 
@@ -245,8 +265,8 @@ type GetNFTHoldersResp struct {
 	NextPageToken string `json:"nextPageToken" bson:"nextPageToken"`
 }
 
-// getNextPageToken returns the next page token for pagination
-func (r *GetNFTHoldersResp) getNextPageToken() string {
+// GetNextPageToken returns the next page token for pagination
+func (r *GetNFTHoldersResp) GetNextPageToken() string {
 	return r.NextPageToken
 }
 
@@ -281,8 +301,8 @@ type GetNFTTransfersReq struct {
 	PageToken string `json:"pageToken,omitempty" bson:"pageToken,omitempty"`
 }
 
-// setPageToken sets the page token for pagination
-func (r *GetNFTTransfersReq) setPageToken(token string) {
+// SetPageToken sets the page token for pagination
+func (r *GetNFTTransfersReq) SetPageToken(token string) {
 	r.PageToken = token
 }
 
@@ -340,8 +360,8 @@ type GetNFTTransfersResp struct {
 	NextPageToken string `json:"nextPageToken" bson:"nextPageToken"`
 }
 
-// getNextPageToken returns the next page token for pagination
-func (r *GetNFTTransfersResp) getNextPageToken() string {
+// GetNextPageToken returns the next page token for pagination
+func (r *GetNFTTransfersResp) GetNextPageToken() string {
 	return r.NextPageToken
 }
 
@@ -370,8 +390,8 @@ type GetAccountBalanceReq struct {
 	WalletAddress string `json:"walletAddress,omitempty" bson:"walletAddress,omitempty"`
 }
 
-// setPageToken sets the page token for pagination
-func (r *GetAccountBalanceReq) setPageToken(token string) {
+// SetPageToken sets the page token for pagination
+func (r *GetAccountBalanceReq) SetPageToken(token string) {
 	r.PageToken = token
 }
 
@@ -426,8 +446,8 @@ type GetAccountBalanceResp struct {
 	TotalBalanceUsd string `json:"totalBalanceUsd" bson:"totalBalanceUsd"`
 }
 
-// getNextPageToken returns the next page token for pagination
-func (r *GetAccountBalanceResp) getNextPageToken() string {
+// GetNextPageToken returns the next page token for pagination
+func (r *GetAccountBalanceResp) GetNextPageToken() string {
 	return r.NextPageToken
 }
 
@@ -502,8 +522,8 @@ type GetTokenHoldersReq struct {
 	PageToken string `json:"pageToken,omitempty" bson:"pageToken,omitempty"`
 }
 
-// setPageToken sets the page token for pagination
-func (r *GetTokenHoldersReq) setPageToken(token string) {
+// SetPageToken sets the page token for pagination
+func (r *GetTokenHoldersReq) SetPageToken(token string) {
 	r.PageToken = token
 }
 
@@ -540,8 +560,8 @@ type GetTokenHoldersResp struct {
 	TokenDecimals int32 `json:"tokenDecimals" bson:"tokenDecimals"`
 }
 
-// getNextPageToken returns the next page token for pagination
-func (r *GetTokenHoldersResp) getNextPageToken() string {
+// GetNextPageToken returns the next page token for pagination
+func (r *GetTokenHoldersResp) GetNextPageToken() string {
 	return r.NextPageToken
 }
 
@@ -561,8 +581,8 @@ type GetTokenHoldersCountReq struct {
 	PageToken string `json:"pageToken,omitempty" bson:"pageToken,omitempty"`
 }
 
-// setPageToken sets the page token for pagination
-func (r *GetTokenHoldersCountReq) setPageToken(token string) {
+// SetPageToken sets the page token for pagination
+func (r *GetTokenHoldersCountReq) SetPageToken(token string) {
 	r.PageToken = token
 }
 
@@ -599,8 +619,8 @@ type GetTokenHoldersCountResp struct {
 	TokenDecimals int32 `json:"tokenDecimals" bson:"tokenDecimals"`
 }
 
-// getNextPageToken returns the next page token for pagination
-func (r *GetTokenHoldersCountResp) getNextPageToken() string {
+// GetNextPageToken returns the next page token for pagination
+func (r *GetTokenHoldersCountResp) GetNextPageToken() string {
 	return r.NextPageToken
 }
 
@@ -640,8 +660,8 @@ type GetTokenTransfersReq struct {
 	PageToken string `json:"pageToken,omitempty" bson:"pageToken,omitempty"`
 }
 
-// setPageToken sets the page token for pagination
-func (r *GetTokenTransfersReq) setPageToken(token string) {
+// SetPageToken sets the page token for pagination
+func (r *GetTokenTransfersReq) SetPageToken(token string) {
 	r.PageToken = token
 }
 
@@ -696,8 +716,8 @@ type GetTokenTransfersResp struct {
 	NextPageToken string `json:"nextPageToken" bson:"nextPageToken"`
 }
 
-// getNextPageToken returns the next page token for pagination
-func (r *GetTokenTransfersResp) getNextPageToken() string {
+// GetNextPageToken returns the next page token for pagination
+func (r *GetTokenTransfersResp) GetNextPageToken() string {
 	return r.NextPageToken
 }
 
@@ -930,8 +950,8 @@ type GetLogsReq struct {
 	Topics [][]string `json:"topics,omitempty" bson:"topics,omitempty"`
 }
 
-// setPageToken sets the page token for pagination
-func (r *GetLogsReq) setPageToken(token string) {
+// SetPageToken sets the page token for pagination
+func (r *GetLogsReq) SetPageToken(token string) {
 	r.PageToken = token
 }
 
@@ -1019,8 +1039,8 @@ type GetLogsResp struct {
 	NextPageToken string `json:"nextPageToken" bson:"nextPageToken"`
 }
 
-// getNextPageToken returns the next page token for pagination
-func (r *GetLogsResp) getNextPageToken() string {
+// GetNextPageToken returns the next page token for pagination
+func (r *GetLogsResp) GetNextPageToken() string {
 	return r.NextPageToken
 }
 
@@ -1162,6 +1182,19 @@ type GetTxsByHashResp struct {
 	Transactions []Tx `json:"transactions" bson:"transactions"`
 }
 
+// cacheable reports whether all transactions are confirmed, so the response won't change
+func (r *GetTxsByHashResp) cacheable() bool {
+	if r == nil || len(r.Transactions) == 0 {
+		return false
+	}
+	for _, tx := range r.Transactions {
+		if tx.BlockHash == "" || tx.BlockNumber == "" {
+			return false
+		}
+	}
+	return true
+}
+
 // GetTxsByAddressReq represents the request parameters for ankr_getTransactionsByAddress
 type GetTxsByAddressReq struct {
 	// Address is the address to search for transactions
@@ -1201,8 +1234,8 @@ type GetTxsByAddressReq struct {
 	PageToken string `json:"pageToken,omitempty" bson:"pageToken,omitempty"`
 }
 
-// setPageToken sets the page token for pagination
-func (r *GetTxsByAddressReq) setPageToken(token string) {
+// SetPageToken sets the page token for pagination
+func (r *GetTxsByAddressReq) SetPageToken(token string) {
 	r.PageToken = token
 }
 
@@ -1215,8 +1248,8 @@ type GetTxsByAddressResp struct {
 	NextPageToken string `json:"nextPageToken" bson:"nextPageToken"`
 }
 
-// getNextPageToken returns the next page token for pagination
-func (r *GetTxsByAddressResp) getNextPageToken() string {
+// GetNextPageToken returns the next page token for pagination
+func (r *GetTxsByAddressResp) GetNextPageToken() string {
 	return r.NextPageToken
 }
 
@@ -0,0 +1,352 @@
+package ankr
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Limits checked by Validate
+const (
+	// MaxNFTPageSize is the maximum PageSize of GetNFTsByOwnerReq
+	MaxNFTPageSize = 50
+
+	// MaxPageSize is the maximum PageSize of the other paginated requests
+	MaxPageSize = 10000
+
+	// MaxBlocksRange is the maximum number of blocks of a GetBlocksReq
+	MaxBlocksRange = 100
+)
+
+// Validator is implemented by requests that can be checked before being sent
+//
+// Calls validate their params after applying defaults, and fail with a *ValidationError
+// without consuming credits or a rate limiter slot.
+type Validator interface {
+	Validate() error
+}
+
+var (
+	addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	hashPattern    = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+	ensPattern     = regexp.MustCompile(`^([a-z0-9-]+\.)+[a-z]{2,}$`)
+)
+
+// validate validates params if they implement Validator
+func validate(params any) error {
+	if v, ok := params.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+func invalid(field, format string, args ...any) *ValidationError {
+	return &ValidationError{Field: field, Reason: fmt.Sprintf(format, args...)}
+}
+
+func checkRequired(field, value string) error {
+	if value == "" {
+		return invalid(field, "is required")
+	}
+	return nil
+}
+
+// checkAddress checks that address is a hex address, or an ENS name if ens is set
+func checkAddress(field, address string, ens bool) error {
+	if addressPattern.MatchString(address) || ens && ensPattern.MatchString(strings.ToLower(address)) {
+		return nil
+	}
+	if ens {
+		return invalid(field, "%q is neither a hex address nor an ENS name", address)
+	}
+	return invalid(field, "%q is not a hex address", address)
+}
+
+func checkAddresses(field string, addresses []string) error {
+	for _, address := range addresses {
+		if err := checkAddress(field, address, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRequiredAddress checks that address is set and is a hex address, or an ENS name if ens is set
+func checkRequiredAddress(field, address string, ens bool) error {
+	if err := checkRequired(field, address); err != nil {
+		return err
+	}
+	return checkAddress(field, address, ens)
+}
+
+func checkPageSize(pageSize int32, limit int32) error {
+	if pageSize < 0 || pageSize > limit {
+		return invalid("PageSize", "%d is out of [0, %d]", pageSize, limit)
+	}
+	return nil
+}
+
+// parseBlock checks a FromBlock or ToBlock value, and returns the block number if it is one
+//
+// Valid values are nil, non-negative integers, hex and decimal strings, "earliest" and "latest".
+func parseBlock(field string, block any) (number int64, ok bool, err error) {
+	switch b := block.(type) {
+	case nil:
+		return 0, false, nil
+	case int:
+		number = int64(b)
+	case int32:
+		number = int64(b)
+	case int64:
+		number = b
+	case uint32:
+		number = int64(b)
+	case uint64:
+		if b > math.MaxInt64 {
+			return 0, false, invalid(field, "%d is out of range", b)
+		}
+		number = int64(b)
+	case float64:
+		// Numbers decoded from JSON
+		if b != math.Trunc(b) || b > math.MaxInt64 {
+			return 0, false, invalid(field, "%v is not a block number", b)
+		}
+		number = int64(b)
+	case string:
+		switch {
+		case b == "earliest", b == "latest":
+			return 0, false, nil
+		case strings.HasPrefix(b, "0x"):
+			number, err = strconv.ParseInt(b[2:], 16, 64)
+		default:
+			number, err = strconv.ParseInt(b, 10, 64)
+		}
+		if err != nil {
+			return 0, false, invalid(field, `%q is neither a block number nor "earliest" or "latest"`, b)
+		}
+	default:
+		return 0, false, invalid(field, "has unsupported type %T", block)
+	}
+	if number < 0 {
+		return 0, false, invalid(field, "%d is negative", number)
+	}
+	return number, true, nil
+}
+
+// checkRange checks a block range and a timestamp range, of which only one may be set
+func checkRange(fromBlock, toBlock any, fromTimestamp, toTimestamp int64) error {
+	from, fromOK, err := parseBlock("FromBlock", fromBlock)
+	if err != nil {
+		return err
+	}
+	to, toOK, err := parseBlock("ToBlock", toBlock)
+	if err != nil {
+		return err
+	}
+	if fromOK && toOK && from > to {
+		return invalid("FromBlock", "%d is after ToBlock %d", from, to)
+	}
+
+	switch {
+	case fromTimestamp < 0:
+		return invalid("FromTimestamp", "%d is negative", fromTimestamp)
+	case toTimestamp < 0:
+		return invalid("ToTimestamp", "%d is negative", toTimestamp)
+	case toTimestamp > 0 && fromTimestamp > toTimestamp:
+		return invalid("FromTimestamp", "%d is after ToTimestamp %d", fromTimestamp, toTimestamp)
+	}
+
+	if (fromBlock != nil || toBlock != nil) && (fromTimestamp != 0 || toTimestamp != 0) {
+		return invalid("FromTimestamp", "can't be combined with a block range")
+	}
+	return nil
+}
+
+// blockOrNil returns block, nil if it is unset
+func blockOrNil(block int64) any {
+	if block == 0 {
+		return nil
+	}
+	return block
+}
+
+// ============================================================================
+// Request Validation
+// ============================================================================
+
+// Validate implements Validator
+func (r GetNFTsByOwnerReq) Validate() error {
+	if err := checkRequiredAddress("WalletAddress", r.WalletAddress, true); err != nil {
+		return err
+	}
+	for contract := range r.Filter {
+		if err := checkAddress("Filter", contract, false); err != nil {
+			return err
+		}
+	}
+	return checkPageSize(r.PageSize, MaxNFTPageSize)
+}
+
+// Validate implements Validator
+func (r GetNFTMetadataReq) Validate() error {
+	if err := checkRequired("Blockchain", string(r.Blockchain)); err != nil {
+		return err
+	}
+	if err := checkRequiredAddress("ContractAddress", r.ContractAddress, true); err != nil {
+		return err
+	}
+	return checkRequired("TokenID", r.TokenID)
+}
+
+// Validate implements Validator
+func (r GetNFTHoldersReq) Validate() error {
+	if err := checkRequired("Blockchain", string(r.Blockchain)); err != nil {
+		return err
+	}
+	if err := checkRequiredAddress("ContractAddress", r.ContractAddress, true); err != nil {
+		return err
+	}
+	return checkPageSize(r.PageSize, MaxPageSize)
+}
+
+// Validate implements Validator
+func (r GetNFTTransfersReq) Validate() error {
+	if err := checkAddresses("Address", r.Address); err != nil {
+		return err
+	}
+	if err := checkRange(blockOrNil(r.FromBlock), blockOrNil(r.ToBlock), r.FromTimestamp, r.ToTimestamp); err != nil {
+		return err
+	}
+	return checkPageSize(r.PageSize, MaxPageSize)
+}
+
+// Validate implements Validator
+func (r GetAccountBalanceReq) Validate() error {
+	if err := checkRequiredAddress("WalletAddress", r.WalletAddress, true); err != nil {
+		return err
+	}
+	return checkPageSize(r.PageSize, math.MaxInt32)
+}
+
+// Validate implements Validator
+func (r GetCurrenciesReq) Validate() error {
+	return checkRequired("Blockchain", string(r.Blockchain))
+}
+
+// Validate implements Validator
+func (r GetTokenPriceReq) Validate() error {
+	if err := checkRequired("Blockchain", string(r.Blockchain)); err != nil {
+		return err
+	}
+	if r.ContractAddress == "" {
+		return nil
+	}
+	return checkAddress("ContractAddress", r.ContractAddress, true)
+}
+
+// Validate implements Validator
+func (r GetTokenHoldersReq) Validate() error {
+	if err := checkRequired("Blockchain", string(r.Blockchain)); err != nil {
+		return err
+	}
+	if err := checkRequiredAddress("ContractAddress", r.ContractAddress, true); err != nil {
+		return err
+	}
+	return checkPageSize(r.PageSize, MaxPageSize)
+}
+
+// Validate implements Validator
+func (r GetTokenHoldersCountReq) Validate() error {
+	if err := checkRequired("Blockchain", string(r.Blockchain)); err != nil {
+		return err
+	}
+	if err := checkRequiredAddress("ContractAddress", r.ContractAddress, true); err != nil {
+		return err
+	}
+	return checkPageSize(r.PageSize, MaxPageSize)
+}
+
+// Validate implements Validator
+func (r GetTokenTransfersReq) Validate() error {
+	if err := checkAddresses("Address", r.Address); err != nil {
+		return err
+	}
+	if err := checkRange(r.FromBlock, r.ToBlock, r.FromTimestamp, r.ToTimestamp); err != nil {
+		return err
+	}
+	return checkPageSize(r.PageSize, MaxPageSize)
+}
+
+// Validate implements Validator
+func (r GetBlockchainStatsReq) Validate() error {
+	return nil
+}
+
+// Validate implements Validator
+//
+// Ranges between block numbers are limited to MaxBlocksRange blocks,
+// ranges involving "earliest" or "latest" are left to the API.
+func (r GetBlocksReq) Validate() error {
+	if err := checkRequired("Blockchain", string(r.Blockchain)); err != nil {
+		return err
+	}
+	if err := checkRange(r.FromBlock, r.ToBlock, 0, 0); err != nil {
+		return err
+	}
+	from, fromOK, _ := parseBlock("FromBlock", r.FromBlock)
+	to, toOK, _ := parseBlock("ToBlock", r.ToBlock)
+	if fromOK && toOK && to-from+1 > MaxBlocksRange {
+		return invalid("ToBlock", "is %d blocks after FromBlock, the maximum range is %d blocks", to-from+1, MaxBlocksRange)
+	}
+	if r.IncludeLogs && r.IncludeTxs != nil && !*r.IncludeTxs {
+		return invalid("IncludeLogs", "requires IncludeTxs, logs are stored inside transactions")
+	}
+	return nil
+}
+
+// Validate implements Validator
+func (r GetLogsReq) Validate() error {
+	if err := checkAddresses("Address", r.Address); err != nil {
+		return err
+	}
+	for _, topics := range r.Topics {
+		for _, topic := range topics {
+			if !hashPattern.MatchString(topic) {
+				return invalid("Topics", "%q is not a 32 bytes hex topic", topic)
+			}
+		}
+	}
+	if err := checkRange(r.FromBlock, r.ToBlock, r.FromTimestamp, r.ToTimestamp); err != nil {
+		return err
+	}
+	return checkPageSize(r.PageSize, MaxPageSize)
+}
+
+// Validate implements Validator
+func (r GetTxsByHashReq) Validate() error {
+	if err := checkRequired("TransactionHash", r.TransactionHash); err != nil {
+		return err
+	}
+	if !hashPattern.MatchString(r.TransactionHash) {
+		return invalid("TransactionHash", "%q is not a 32 bytes hex hash", r.TransactionHash)
+	}
+	return nil
+}
+
+// Validate implements Validator
+func (r GetTxsByAddressReq) Validate() error {
+	if err := checkRequiredAddress("Address", r.Address, false); err != nil {
+		return err
+	}
+	if err := checkRange(r.FromBlock, r.ToBlock, r.FromTimestamp, r.ToTimestamp); err != nil {
+		return err
+	}
+	return checkPageSize(r.PageSize, MaxPageSize)
+}
+
+// Validate implements Validator
+func (r GetInteractionsReq) Validate() error {
+	return checkRequiredAddress("Address", r.Address, false)
+}
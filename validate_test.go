@@ -0,0 +1,95 @@
+package ankr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// TestValidate tests the checks of the Validate methods of requests
+func TestValidate(t *testing.T) {
+	const (
+		wallet   = "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"
+		contract = "0xBC4CA0EdA7647A8aB7C2061c2E118A18a936f13D"
+		topic    = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	)
+	tests := []struct {
+		name  string
+		req   Validator
+		field string // empty if the request is valid
+	}{
+		{"nfts by ENS", GetNFTsByOwnerReq{WalletAddress: "vitalik.eth", PageSize: 50}, ""},
+		{"nfts without wallet", GetNFTsByOwnerReq{}, "WalletAddress"},
+		{"nfts page size", GetNFTsByOwnerReq{WalletAddress: wallet, PageSize: 51}, "PageSize"},
+		{"nfts filter", GetNFTsByOwnerReq{WalletAddress: wallet, Filter: map[string][]string{"0x123": nil}}, "Filter"},
+		{"metadata", GetNFTMetadataReq{Blockchain: ChainEthereum, ContractAddress: contract, TokenID: "1"}, ""},
+		{"metadata without contract", GetNFTMetadataReq{Blockchain: ChainEthereum, TokenID: "1"}, "ContractAddress"},
+		{"metadata without token", GetNFTMetadataReq{Blockchain: ChainEthereum, ContractAddress: contract}, "TokenID"},
+		{"holders without chain", GetTokenHoldersReq{ContractAddress: contract}, "Blockchain"},
+		{"price of native coin", GetTokenPriceReq{Blockchain: ChainEthereum}, ""},
+		{"price of bad contract", GetTokenPriceReq{Blockchain: ChainEthereum, ContractAddress: "0xzz"}, "ContractAddress"},
+		{"logs", GetLogsReq{Address: []string{contract}, FromBlock: "0x10", ToBlock: int64(20), Topics: [][]string{{topic}}, PageSize: 10000}, ""},
+		{"logs page size", GetLogsReq{PageSize: 10001}, "PageSize"},
+		{"logs topic", GetLogsReq{Topics: [][]string{{"0x1234"}}}, "Topics"},
+		{"logs ranges combined", GetLogsReq{FromBlock: "latest", ToTimestamp: 1700000000}, "FromTimestamp"},
+		{"logs bad from block", GetLogsReq{FromBlock: "pending"}, "FromBlock"},
+		{"logs negative from block", GetLogsReq{FromBlock: -1}, "FromBlock"},
+		{"logs unsupported block", GetLogsReq{FromBlock: true}, "FromBlock"},
+		{"logs reversed range", GetLogsReq{FromBlock: 20, ToBlock: "0x10"}, "FromBlock"},
+		{"logs reversed timestamps", GetLogsReq{FromTimestamp: 20, ToTimestamp: 10}, "FromTimestamp"},
+		{"blocks", GetBlocksReq{Blockchain: ChainEthereum, FromBlock: 1, ToBlock: 100}, ""},
+		{"blocks latest", GetBlocksReq{Blockchain: ChainEthereum, FromBlock: "earliest", ToBlock: "latest"}, ""},
+		{"blocks range", GetBlocksReq{Blockchain: ChainEthereum, FromBlock: 1, ToBlock: 101}, "ToBlock"},
+		{"blocks logs without txs", GetBlocksReq{Blockchain: ChainEthereum, IncludeLogs: true, IncludeTxs: FalsePtr()}, "IncludeLogs"},
+		{"token transfers decimal block", GetTokenTransfersReq{Address: []string{wallet}, FromBlock: "100"}, ""},
+		{"token transfers address", GetTokenTransfersReq{Address: []string{"vitalik.eth"}}, "Address"},
+		{"nft transfers ranges combined", GetNFTTransfersReq{FromBlock: 1, FromTimestamp: 1}, "FromTimestamp"},
+		{"txs by hash", GetTxsByHashReq{TransactionHash: topic}, ""},
+		{"txs by short hash", GetTxsByHashReq{TransactionHash: "0xabc"}, "TransactionHash"},
+		{"txs by address", GetTxsByAddressReq{}, "Address"},
+		{"interactions", GetInteractionsReq{Address: wallet}, ""},
+	}
+	for _, tt := range tests {
+		err := tt.req.Validate()
+		var validationErr *ValidationError
+		switch {
+		case tt.field == "" && err != nil:
+			t.Errorf("%s: expected a valid request, got %v", tt.name, err)
+		case tt.field != "" && !errors.As(err, &validationErr):
+			t.Errorf("%s: expected a validation error, got %v", tt.name, err)
+		case tt.field != "" && validationErr.Field != tt.field:
+			t.Errorf("%s: expected %s to be invalid, got %v", tt.name, tt.field, err)
+		}
+	}
+}
+
+// TestValidateBeforeSending tests that invalid requests fail without being sent, charged nor retried
+func TestValidateBeforeSending(t *testing.T) {
+	var calls int
+	client := newStandInClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		writeRPCResult(t, w, 1, GetBlocksResp{})
+	}, HTTPClientConfig{})
+
+	_, err := client.GetBlocks(context.Background(), GetBlocksReq{Blockchain: ChainEthereum, FromBlock: 0, ToBlock: 500})
+	if !errors.Is(err, ErrInvalidRequest) || IsRetryable(err) {
+		t.Errorf("Expected a non-retryable ErrInvalidRequest, got %v", err)
+	}
+
+	batch := client.NewBatch()
+	result := batch.GetCurrencies(GetCurrenciesReq{})
+	if !errors.Is(result.Err, ErrInvalidRequest) || batch.Len() != 0 {
+		t.Errorf("Expected the invalid call not to be queued, got %v", result.Err)
+	}
+
+	for _, err := range client.StreamLogs(context.Background(), GetLogsReq{PageSize: MaxPageSize + 1}) {
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("Expected the stream to fail validation, got %v", err)
+		}
+	}
+
+	if calls != 0 || client.Credits().Total != 0 {
+		t.Errorf("Expected nothing to be sent nor charged, got %d requests", calls)
+	}
+}